@@ -4,6 +4,7 @@ go 1.21
 
 require (
 	github.com/hashicorp/boundary/sdk v0.0.47
+	github.com/hashicorp/go-bexpr v0.1.14
 	github.com/hashicorp/go-secure-stdlib/parseutil v0.1.8
 	google.golang.org/api v0.188.0
 )
@@ -17,6 +18,7 @@ require (
 	github.com/go-logr/stdr v1.2.2 // indirect
 	github.com/golang/groupcache v0.0.0-20210331224755-41bb18bfe9da // indirect
 	github.com/google/s2a-go v0.1.7 // indirect
+	github.com/google/uuid v1.6.0 // indirect
 	github.com/googleapis/enterprise-certificate-proxy v0.3.2 // indirect
 	github.com/googleapis/gax-go/v2 v2.12.5 // indirect
 	github.com/hashicorp/go-secure-stdlib/strutil v0.1.2 // indirect
@@ -0,0 +1,241 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package filter evaluates Compute Engine list filter expressions
+// locally, against any proto message. It is used where the Compute API
+// cannot apply a filter itself, e.g. on the members of an instance group.
+//
+// The supported grammar follows AIP-160, which the Compute API filter
+// is based on:
+//
+//	expression  = sequence { "AND" sequence }
+//	sequence    = factor { factor }
+//	factor      = term { "OR" term }
+//	term        = [ "NOT" | "-" ] simple
+//	simple      = restriction | "(" expression ")"
+//	restriction = field comparator value
+//	comparator  = "=" | "!=" | "<" | "<=" | ">" | ">=" | ":" | "eq" | "ne"
+//
+// Adjacent factors are combined with AND, and OR binds tighter than AND.
+// Fields are dot separated paths into the JSON representation of the
+// message, e.g. labels.env or networkInterfaces.network. Values may be
+// quoted or bare, and may contain * wildcards. The eq and ne comparators
+// match the value as a regular expression against the whole field.
+package filter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Filter is a parsed filter expression.
+type Filter struct {
+	expression node
+}
+
+// Parse parses a filter expression. The returned error is a
+// *SyntaxError when the expression is malformed.
+func Parse(s string) (*Filter, error) {
+	p := &parser{input: s}
+	expression, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if !p.done() {
+		if p.peek() == ')' {
+			return nil, p.errorf("unbalanced parenthesis")
+		}
+		return nil, p.errorf("unexpected %q", p.input[p.pos:])
+	}
+	return &Filter{expression: expression}, nil
+}
+
+// Matches reports whether the message matches the filter.
+func (f *Filter) Matches(m proto.Message) (bool, error) {
+	raw, err := protojson.Marshal(m)
+	if err != nil {
+		return false, fmt.Errorf("error marshaling %s: %w", m.ProtoReflect().Descriptor().Name(), err)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return false, fmt.Errorf("error unmarshaling %s: %w", m.ProtoReflect().Descriptor().Name(), err)
+	}
+	return f.expression.eval(doc), nil
+}
+
+// UsesRegexp reports whether the filter compares any field with the eq
+// or ne regular expression comparators. The Compute API rejects filters
+// mixing these comparators with the other ones.
+func (f *Filter) UsesRegexp() bool {
+	for _, r := range restrictions(f.expression) {
+		if r.comparator == "eq" || r.comparator == "ne" {
+			return true
+		}
+	}
+	return false
+}
+
+// SyntaxError describes a malformed filter expression. Pos is the
+// zero based byte offset in the expression where the error was found.
+type SyntaxError struct {
+	Pos int
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Msg, e.Pos)
+}
+
+type node interface {
+	eval(doc map[string]any) bool
+}
+
+type andNode struct {
+	left, right node
+}
+
+func (n *andNode) eval(doc map[string]any) bool {
+	return n.left.eval(doc) && n.right.eval(doc)
+}
+
+type orNode struct {
+	left, right node
+}
+
+func (n *orNode) eval(doc map[string]any) bool {
+	return n.left.eval(doc) || n.right.eval(doc)
+}
+
+type notNode struct {
+	node node
+}
+
+func (n *notNode) eval(doc map[string]any) bool {
+	return !n.node.eval(doc)
+}
+
+// restriction is a single comparison of a field with a value.
+type restriction struct {
+	field      []string
+	comparator string
+	value      string
+	pos        int
+	matcher    func(string) bool
+}
+
+func (r *restriction) eval(doc map[string]any) bool {
+	values := resolve(doc, r.field)
+	switch r.comparator {
+	case "!=", "ne":
+		for _, v := range values {
+			if r.matches(v) {
+				return false
+			}
+		}
+		return true
+	case ":":
+		if r.value == "*" {
+			return len(values) > 0
+		}
+		for _, v := range values {
+			if m, ok := v.(map[string]any); ok {
+				if _, ok := m[r.value]; ok {
+					return true
+				}
+				continue
+			}
+			if r.matches(v) {
+				return true
+			}
+		}
+		return false
+	default:
+		for _, v := range values {
+			if r.matches(v) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// matches compares a single resolved value with the restriction value.
+func (r *restriction) matches(v any) bool {
+	s, ok := scalarString(v)
+	if !ok {
+		return false
+	}
+	switch r.comparator {
+	case "<", "<=", ">", ">=":
+		c := compare(s, r.value)
+		switch r.comparator {
+		case "<":
+			return c < 0
+		case "<=":
+			return c <= 0
+		case ">":
+			return c > 0
+		default:
+			return c >= 0
+		}
+	case "=", "!=", ":":
+		if c, ok := compareNumbers(s, r.value); ok {
+			return c == 0
+		}
+		return r.matcher(s)
+	default:
+		return r.matcher(s)
+	}
+}
+
+// resolve returns the values found at the field path. Repeated fields
+// are flattened, so a path through a list returns the values of every
+// element.
+func resolve(v any, path []string) []any {
+	if len(path) == 0 {
+		if v == nil {
+			return nil
+		}
+		if list, ok := v.([]any); ok {
+			return list
+		}
+		return []any{v}
+	}
+
+	switch val := v.(type) {
+	case map[string]any:
+		next, ok := val[path[0]]
+		if !ok {
+			next, ok = val[lowerCamel(path[0])]
+		}
+		if !ok {
+			return nil
+		}
+		return resolve(next, path[1:])
+	case []any:
+		var out []any
+		for _, elem := range val {
+			out = append(out, resolve(elem, path)...)
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
+// lowerCamel converts a snake_case field name to the lowerCamelCase
+// JSON name used by protojson.
+func lowerCamel(s string) string {
+	parts := strings.Split(s, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
@@ -0,0 +1,185 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package filter
+
+import (
+	"testing"
+
+	"cloud.google.com/go/compute/apiv1/computepb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestFilterMatches(t *testing.T) {
+	instance := &computepb.Instance{
+		Id:          proto.Uint64(1234),
+		Name:        proto.String("web-1"),
+		Status:      proto.String("RUNNING"),
+		MachineType: proto.String("https://www.googleapis.com/compute/v1/projects/test-project/zones/us-central1-a/machineTypes/e2-medium"),
+		Labels: map[string]string{
+			"tier":        "web",
+			"cost_center": "42",
+		},
+		Tags: &computepb.Tags{
+			Items: []string{"http-server", "ssh"},
+		},
+		NetworkInterfaces: []*computepb.NetworkInterface{
+			{
+				Network:   proto.String("https://www.googleapis.com/compute/v1/projects/test-project/global/networks/default"),
+				NetworkIP: proto.String("10.0.0.2"),
+			},
+		},
+		Scheduling: &computepb.Scheduling{
+			AutomaticRestart: proto.Bool(true),
+		},
+		CreationTimestamp: proto.String("2024-01-02T03:04:05.000-07:00"),
+	}
+
+	cases := []struct {
+		filter   string
+		expected bool
+	}{
+		{filter: "name = web-1", expected: true},
+		{filter: `name = "web-1"`, expected: true},
+		{filter: "name=web-2", expected: false},
+		{filter: "name != web-2", expected: true},
+		{filter: "name = web-*", expected: true},
+		{filter: `name = "web-*"`, expected: true},
+		{filter: `name = "db-*"`, expected: false},
+		{filter: "machineType = *e2-medium", expected: true},
+		{filter: "name eq 'web-[0-9]+'", expected: true},
+		{filter: "name ne 'web-.*'", expected: false},
+		{filter: "status = RUNNING AND labels.tier = web", expected: true},
+		{filter: "status = RUNNING labels.tier = web", expected: true},
+		{filter: "status = TERMINATED OR labels.tier = web", expected: true},
+		{filter: "(status = TERMINATED) OR (labels.tier = db)", expected: false},
+		{filter: "NOT status = TERMINATED", expected: true},
+		{filter: "-labels.tier = web", expected: false},
+		{filter: "labels.tier:*", expected: true},
+		{filter: "labels.env:*", expected: false},
+		{filter: "labels:tier", expected: true},
+		{filter: "labels.cost_center = 42", expected: true},
+		{filter: "labels.env != prod", expected: true},
+		{filter: "tags.items = ssh", expected: true},
+		{filter: "tags.items:http-*", expected: true},
+		{filter: "networkInterfaces.network = *networks/default", expected: true},
+		{filter: "network_interfaces.network_i_p = 10.0.0.2", expected: true},
+		{filter: "scheduling.automaticRestart = true", expected: true},
+		{filter: "id > 1000 AND id <= 1234", expected: true},
+		{filter: "id < 1000", expected: false},
+		{filter: `creationTimestamp > "2024-01-01"`, expected: true},
+		{filter: "status = RUNNING AND labels.tier = db OR labels.tier = web", expected: true},
+		{filter: "labels.tier = db OR labels.tier = web AND status = TERMINATED", expected: false},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.filter, func(t *testing.T) {
+			require := require.New(t)
+
+			f, err := Parse(tc.filter)
+			require.NoError(err)
+
+			actual, err := f.Matches(instance)
+			require.NoError(err)
+			require.Equal(tc.expected, actual)
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	cases := []struct {
+		filter      string
+		expectedErr string
+	}{
+		{filter: "", expectedErr: "unexpected end of filter at position 0"},
+		{filter: "name", expectedErr: `expected comparison operator after "name" at position 4`},
+		{filter: "name ~ web", expectedErr: `invalid comparison operator after "name" at position 5`},
+		{filter: "name =", expectedErr: "expected value at position 6"},
+		{filter: "name==web", expectedErr: `unexpected "=" at start of value at position 5`},
+		{filter: "id => 10", expectedErr: `unexpected ">" at start of value at position 4`},
+		{filter: `name = "web`, expectedErr: "unterminated quoted string at position 7"},
+		{filter: "(name = web", expectedErr: "unbalanced parenthesis at position 0"},
+		{filter: "name = web)", expectedErr: "unbalanced parenthesis at position 10"},
+		{filter: "name = web AND", expectedErr: "unexpected end of filter at position 14"},
+		{filter: "name eq '[a-'", expectedErr: `invalid regular expression "[a-" at position 8`},
+		{filter: "id > 10*", expectedErr: `wildcards cannot be used with ">" at position 3`},
+		{filter: "labels..env = x", expectedErr: `invalid field name "labels..env" at position 0`},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.filter, func(t *testing.T) {
+			require := require.New(t)
+
+			_, err := Parse(tc.filter)
+			require.EqualError(err, tc.expectedErr)
+
+			var syntaxErr *SyntaxError
+			require.ErrorAs(err, &syntaxErr)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	md := (&computepb.Instance{}).ProtoReflect().Descriptor()
+
+	cases := []struct {
+		filter      string
+		expectedErr string
+	}{
+		{filter: "status = RUNNING"},
+		{filter: "labels.tier = web AND labels:env"},
+		{filter: "networkInterfaces.accessConfigs.natIP:*"},
+		{filter: "network_interfaces.network = default"},
+		{filter: "scheduling.automaticRestart = true"},
+		{filter: "stauts = RUNNING", expectedErr: `unknown field "stauts" at position 0`},
+		{filter: "status = RUNNING AND -tags.itms = ssh", expectedErr: `unknown field "tags.itms" at position 22`},
+		{filter: "name.first = web", expectedErr: `unknown field "name.first" at position 0`},
+		{filter: "labels.tier.name = web", expectedErr: `unknown field "labels.tier.name" at position 0`},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.filter, func(t *testing.T) {
+			require := require.New(t)
+
+			f, err := Parse(tc.filter)
+			require.NoError(err)
+
+			err = f.Validate(md)
+			if tc.expectedErr != "" {
+				require.EqualError(err, tc.expectedErr)
+				return
+			}
+			require.NoError(err)
+		})
+	}
+}
+
+func TestMatchWildcard(t *testing.T) {
+	require := require.New(t)
+
+	require.True(MatchWildcard("db-admin", "db-admin"))
+	require.False(MatchWildcard("db-admin", "db-admins"))
+	require.True(MatchWildcard("db-*", "db-admin"))
+	require.True(MatchWildcard("*", ""))
+	require.True(MatchWildcard("https://*/instanceTemplates/web-*", "https://example.com/projects/p/global/instanceTemplates/web-2"))
+	require.False(MatchWildcard("db.*", "dbxadmin"))
+}
+
+func TestUsesRegexp(t *testing.T) {
+	require := require.New(t)
+
+	for expression, expected := range map[string]bool{
+		`name eq web-.*`:                     true,
+		`(name ne db-.*) (zone eq .*-a)`:     true,
+		`name = "web-*" AND labels.env:*`:    false,
+		`status = RUNNING OR -name = "db-1"`: false,
+	} {
+		f, err := Parse(expression)
+		require.NoError(err)
+		require.Equal(expected, f.UsesRegexp(), expression)
+	}
+}
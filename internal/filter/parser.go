@@ -0,0 +1,329 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type parser struct {
+	input string
+	pos   int
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return p.errorAt(p.pos, format, args...)
+}
+
+func (p *parser) errorAt(pos int, format string, args ...any) error {
+	return &SyntaxError{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.input)
+}
+
+func (p *parser) peek() byte {
+	if p.done() {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+func (p *parser) skipSpace() {
+	for !p.done() && isSpace(p.input[p.pos]) {
+		p.pos++
+	}
+}
+
+// keyword reports whether the input at the current position is the
+// given keyword, followed by a delimiter.
+func (p *parser) keyword(kw string) bool {
+	if !strings.HasPrefix(p.input[p.pos:], kw) {
+		return false
+	}
+	end := p.pos + len(kw)
+	return end == len(p.input) || isSpace(p.input[end]) || p.input[end] == '(' || p.input[end] == ')'
+}
+
+func (p *parser) parseExpression() (node, error) {
+	left, err := p.parseSequence()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skipSpace()
+		if !p.keyword("AND") {
+			return left, nil
+		}
+		p.pos += len("AND")
+		right, err := p.parseSequence()
+		if err != nil {
+			return nil, err
+		}
+		left = &andNode{left: left, right: right}
+	}
+}
+
+func (p *parser) parseSequence() (node, error) {
+	left, err := p.parseFactor()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skipSpace()
+		if p.done() || p.peek() == ')' || p.keyword("AND") {
+			return left, nil
+		}
+		right, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		left = &andNode{left: left, right: right}
+	}
+}
+
+func (p *parser) parseFactor() (node, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skipSpace()
+		if !p.keyword("OR") {
+			return left, nil
+		}
+		p.pos += len("OR")
+		right, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		left = &orNode{left: left, right: right}
+	}
+}
+
+func (p *parser) parseTerm() (node, error) {
+	p.skipSpace()
+	negate := false
+	switch {
+	case p.keyword("NOT"):
+		p.pos += len("NOT")
+		negate = true
+	case p.peek() == '-':
+		p.pos++
+		negate = true
+	}
+	simple, err := p.parseSimple()
+	if err != nil {
+		return nil, err
+	}
+	if negate {
+		return &notNode{node: simple}, nil
+	}
+	return simple, nil
+}
+
+func (p *parser) parseSimple() (node, error) {
+	p.skipSpace()
+	if p.done() {
+		return nil, p.errorf("unexpected end of filter")
+	}
+	if p.peek() != '(' {
+		return p.parseRestriction()
+	}
+
+	open := p.pos
+	p.pos++
+	expression, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.peek() != ')' {
+		return nil, p.errorAt(open, "unbalanced parenthesis")
+	}
+	p.pos++
+	return expression, nil
+}
+
+func (p *parser) parseRestriction() (node, error) {
+	start := p.pos
+	for !p.done() && isFieldChar(p.input[p.pos], p.pos == start) {
+		p.pos++
+	}
+	field := p.input[start:p.pos]
+	if field == "" {
+		return nil, p.errorf("expected field name, got %q", string(p.peek()))
+	}
+	if strings.HasPrefix(field, ".") || strings.HasSuffix(field, ".") || strings.Contains(field, "..") {
+		return nil, p.errorAt(start, "invalid field name %q", field)
+	}
+
+	p.skipSpace()
+	comparatorPos := p.pos
+	comparator := p.parseComparator()
+	if comparator == "" {
+		if p.done() {
+			return nil, p.errorf("expected comparison operator after %q", field)
+		}
+		return nil, p.errorf("invalid comparison operator after %q", field)
+	}
+
+	p.skipSpace()
+	valuePos := p.pos
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+
+	r := &restriction{
+		field:      strings.Split(field, "."),
+		comparator: comparator,
+		value:      value,
+		pos:        start,
+	}
+	switch {
+	case comparator == "eq" || comparator == "ne":
+		re, err := regexp.Compile("^(?:" + value + ")$")
+		if err != nil {
+			return nil, p.errorAt(valuePos, "invalid regular expression %q", value)
+		}
+		r.matcher = re.MatchString
+	case strings.Contains(value, "*"):
+		if strings.ContainsAny(comparator, "<>") {
+			return nil, p.errorAt(comparatorPos, "wildcards cannot be used with %q", comparator)
+		}
+		r.matcher = wildcardRegexp(value).MatchString
+	default:
+		r.matcher = func(s string) bool {
+			return s == value
+		}
+	}
+	return r, nil
+}
+
+// parseComparator consumes and returns the comparator at the current
+// position, or returns an empty string if there is none.
+func (p *parser) parseComparator() string {
+	for _, c := range []string{"!=", "<=", ">=", "=", "<", ">", ":"} {
+		if strings.HasPrefix(p.input[p.pos:], c) {
+			p.pos += len(c)
+			return c
+		}
+	}
+	for _, c := range []string{"eq", "ne"} {
+		if p.keyword(c) {
+			p.pos += len(c)
+			return c
+		}
+	}
+	return ""
+}
+
+// parseValue consumes a quoted or bare value. Quoting allows spaces and
+// parentheses in a value and does not change how wildcards match.
+func (p *parser) parseValue() (string, error) {
+	if p.done() {
+		return "", p.errorf("expected value")
+	}
+
+	if quote := p.peek(); quote == '"' || quote == '\'' {
+		start := p.pos
+		p.pos++
+		var b strings.Builder
+		for !p.done() {
+			c := p.input[p.pos]
+			switch {
+			case c == '\\' && p.pos+1 < len(p.input):
+				b.WriteByte(p.input[p.pos+1])
+				p.pos += 2
+			case c == quote:
+				p.pos++
+				return b.String(), nil
+			default:
+				b.WriteByte(c)
+				p.pos++
+			}
+		}
+		return "", p.errorAt(start, "unterminated quoted string")
+	}
+
+	// A bare value starting with an operator character is most likely a
+	// mistyped comparator such as == or =>.
+	if c := p.peek(); strings.IndexByte("=<>!:", c) >= 0 {
+		return "", p.errorf("unexpected %q at start of value", string(c))
+	}
+
+	start := p.pos
+	for !p.done() && !isSpace(p.input[p.pos]) && p.input[p.pos] != '(' && p.input[p.pos] != ')' {
+		if c := p.input[p.pos]; c == '"' || c == '\'' {
+			return "", p.errorf("unexpected quote in value")
+		}
+		p.pos++
+	}
+	if start == p.pos {
+		return "", p.errorf("expected value")
+	}
+	return p.input[start:p.pos], nil
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func isFieldChar(c byte, first bool) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '_':
+		return true
+	case c >= '0' && c <= '9', c == '.', c == '-':
+		return !first
+	default:
+		return false
+	}
+}
+
+// scalarString returns the string form of a JSON scalar.
+func scalarString(v any) (string, bool) {
+	switch val := v.(type) {
+	case string:
+		return val, true
+	case bool:
+		return strconv.FormatBool(val), true
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64), true
+	default:
+		return "", false
+	}
+}
+
+// compare compares the values numerically when both are numbers, and
+// lexically otherwise.
+func compare(a, b string) int {
+	if c, ok := compareNumbers(a, b); ok {
+		return c
+	}
+	return strings.Compare(a, b)
+}
+
+func compareNumbers(a, b string) (int, bool) {
+	x, err := strconv.ParseFloat(a, 64)
+	if err != nil {
+		return 0, false
+	}
+	y, err := strconv.ParseFloat(b, 64)
+	if err != nil {
+		return 0, false
+	}
+	switch {
+	case x < y:
+		return -1, true
+	case x > y:
+		return 1, true
+	default:
+		return 0, true
+	}
+}
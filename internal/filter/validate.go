@@ -0,0 +1,69 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package filter
+
+import (
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Validate checks that every field referenced by the filter exists in
+// the message described by md. The returned error is a *SyntaxError
+// pointing at the first unknown field.
+func (f *Filter) Validate(md protoreflect.MessageDescriptor) error {
+	for _, r := range restrictions(f.expression) {
+		if !fieldExists(md, r.field) {
+			return &SyntaxError{Pos: r.pos, Msg: "unknown field \"" + strings.Join(r.field, ".") + "\""}
+		}
+	}
+	return nil
+}
+
+// restrictions returns the restrictions of an expression in the order
+// they appear in the filter.
+func restrictions(n node) []*restriction {
+	switch val := n.(type) {
+	case *andNode:
+		return append(restrictions(val.left), restrictions(val.right)...)
+	case *orNode:
+		return append(restrictions(val.left), restrictions(val.right)...)
+	case *notNode:
+		return restrictions(val.node)
+	case *restriction:
+		return []*restriction{val}
+	default:
+		return nil
+	}
+}
+
+// fieldExists reports whether the field path can be resolved in the
+// message. Fields may be referenced by their JSON or proto name, and the
+// segment following a map field is a map key.
+func fieldExists(md protoreflect.MessageDescriptor, path []string) bool {
+	for i := 0; i < len(path); i++ {
+		if md == nil {
+			return false
+		}
+		fields := md.Fields()
+		fd := fields.ByJSONName(path[i])
+		if fd == nil {
+			fd = fields.ByName(protoreflect.Name(path[i]))
+		}
+		if fd == nil {
+			return false
+		}
+
+		if fd.IsMap() {
+			if i+1 == len(path) {
+				return true
+			}
+			// Skip the map key.
+			i++
+			fd = fd.MapValue()
+		}
+		md = fd.Message()
+	}
+	return true
+}
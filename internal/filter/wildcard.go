@@ -0,0 +1,28 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package filter
+
+import (
+	"regexp"
+	"strings"
+)
+
+// MatchWildcard reports whether s matches the pattern, where every * in
+// the pattern matches any sequence of characters, including none.
+func MatchWildcard(pattern, s string) bool {
+	if !strings.Contains(pattern, "*") {
+		return pattern == s
+	}
+	return wildcardRegexp(pattern).MatchString(s)
+}
+
+// wildcardRegexp returns a regular expression matching the value, with
+// every * matching any sequence of characters.
+func wildcardRegexp(value string) *regexp.Regexp {
+	parts := strings.Split(value, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	return regexp.MustCompile("^" + strings.Join(parts, ".*") + "$")
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/hashicorp/go-secure-stdlib/parseutil"
 	"google.golang.org/protobuf/types/known/structpb"
 )
 
@@ -84,6 +85,63 @@ func GetTimeValue(in *structpb.Struct, k string) (time.Time, error) {
 	return t, nil
 }
 
+// GetDurationValue returns a time.Duration value and no error if the given
+// key is found in the provided proto struct input. An error is returned
+// if the value type is not a parsable duration or if the duration is
+// negative. Durations are strings such as "90s", "15m" or "90d", or
+// numbers of seconds. A zero duration is returned if the key is not found.
+func GetDurationValue(in *structpb.Struct, k string) (time.Duration, error) {
+	mv := in.GetFields()
+	v, ok := mv[k]
+	if !ok {
+		return 0, nil
+	}
+
+	switch raw := v.AsInterface().(type) {
+	case string, float64:
+		d, err := parseutil.ParseDurationSecond(raw)
+		if err != nil {
+			return 0, fmt.Errorf("could not parse duration in value %q: %w", k, err)
+		}
+		if d < 0 {
+			return 0, fmt.Errorf("duration in value %q cannot be negative", k)
+		}
+		return d, nil
+	default:
+		return 0, fmt.Errorf("unexpected type for value %q: want string or number, got %T", k, v.AsInterface())
+	}
+}
+
+// GetStringSliceValue returns a []string value and no error if the given
+// key is found in the provided proto struct input. A single string is
+// returned as a one element slice. An error is returned if the value type
+// is not a string or a list of strings. A nil slice is returned if the key
+// is not found.
+func GetStringSliceValue(in *structpb.Struct, k string) ([]string, error) {
+	mv := in.GetFields()
+	v, ok := mv[k]
+	if !ok {
+		return nil, nil
+	}
+
+	switch raw := v.AsInterface().(type) {
+	case string:
+		return []string{raw}, nil
+	case []any:
+		result := make([]string, 0, len(raw))
+		for i, elem := range raw {
+			s, ok := elem.(string)
+			if !ok {
+				return nil, fmt.Errorf("unexpected type for value in list[%d]: want string, got %T", i, elem)
+			}
+			result = append(result, s)
+		}
+		return result, nil
+	default:
+		return nil, fmt.Errorf("unexpected type for value %q: want string or list of strings, got %T", k, v.AsInterface())
+	}
+}
+
 // GetMapStringString returns a map[string]string value and no error if the given key
 // is found in the provided proto struct input. An error is returned if the key
 // is not found or the value type is not map[string]string.
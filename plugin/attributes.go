@@ -5,10 +5,16 @@ package plugin
 
 import (
 	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
 
 	computepb "cloud.google.com/go/compute/apiv1/computepb"
 	cred "github.com/joatmon08/boundary-plugin-google/internal/credential"
 	"github.com/joatmon08/boundary-plugin-google/internal/errors"
+	"github.com/joatmon08/boundary-plugin-google/internal/filter"
 	"github.com/joatmon08/boundary-plugin-google/internal/values"
 	"github.com/mitchellh/mapstructure"
 	"google.golang.org/grpc/codes"
@@ -16,8 +22,21 @@ import (
 	"google.golang.org/protobuf/types/known/structpb"
 )
 
+var (
+	labelKeyRegexp   = regexp.MustCompile(`^[\p{Ll}\p{Lo}][\p{Ll}\p{Lo}\p{N}_-]{0,62}$`)
+	labelValueRegexp = regexp.MustCompile(`^[\p{Ll}\p{Lo}\p{N}_-]{0,63}$`)
+)
+
 type CatalogAttributes struct {
 	*cred.CredentialAttributes
+
+	// DefaultStatuses are the instance statuses included in sets that do
+	// not set their own statuses.
+	DefaultStatuses []string
+
+	// OnMissingAddress is what to do with instances without any address
+	// in sets that do not set their own policy.
+	OnMissingAddress string
 }
 
 func getCatalogAttributes(in *structpb.Struct) (*CatalogAttributes, error) {
@@ -37,23 +56,138 @@ func getCatalogAttributes(in *structpb.Struct) (*CatalogAttributes, error) {
 			continue
 		case cred.ConstZone:
 			continue
+		case ConstDefaultStatuses:
+			continue
+		case ConstOnMissingAddress:
+			continue
 		default:
 			badFields[fmt.Sprintf("attributes.%s", s)] = "unrecognized field"
 		}
 	}
 
+	defaultStatuses, err := values.GetStringSliceValue(in, ConstDefaultStatuses)
+	switch {
+	case err != nil:
+		badFields[fmt.Sprintf("attributes.%s", ConstDefaultStatuses)] = err.Error()
+	case defaultStatuses == nil:
+		defaultStatuses = []string{ConstStatusRunning}
+	case len(defaultStatuses) == 0:
+		badFields[fmt.Sprintf("attributes.%s", ConstDefaultStatuses)] = "must not be empty"
+	default:
+		if err := validateStatuses(defaultStatuses); err != nil {
+			badFields[fmt.Sprintf("attributes.%s", ConstDefaultStatuses)] = err.Error()
+		}
+	}
+
+	onMissingAddress, err := values.GetStringValue(in, ConstOnMissingAddress, false)
+	switch {
+	case err != nil:
+		badFields[fmt.Sprintf("attributes.%s", ConstOnMissingAddress)] = err.Error()
+	case onMissingAddress == "":
+		onMissingAddress = ConstMissingAddressSkip
+	default:
+		if err := validateMissingAddressPolicy(onMissingAddress); err != nil {
+			badFields[fmt.Sprintf("attributes.%s", ConstOnMissingAddress)] = err.Error()
+		}
+	}
+
 	if len(badFields) > 0 {
 		return nil, errors.InvalidArgumentError("Invalid arguments in catalog attributes", badFields)
 	}
 
 	return &CatalogAttributes{
 		CredentialAttributes: credAttributes,
+		DefaultStatuses:      defaultStatuses,
+		OnMissingAddress:     onMissingAddress,
 	}, nil
 }
 
 type SetAttributes struct {
-	Filter        string `mapstructure:"filter"`
-	InstanceGroup string `mapstructure:"instance_group"`
+	// Type is the kind of resource selected by the set, Compute Engine
+	// instances unless set.
+	Type       string `mapstructure:"type"`
+	NamePrefix string `mapstructure:"name_prefix"`
+
+	Filter           string   `mapstructure:"filter"`
+	Filters          []string `mapstructure:"filters"`
+	InstanceGroup    string   `mapstructure:"instance_group"`
+	AddressKinds     []string `mapstructure:"address_kinds"`
+	AddressFamilies  []string `mapstructure:"address_families"`
+	Network          string   `mapstructure:"network"`
+	Subnetwork       string   `mapstructure:"subnetwork"`
+	NetworkTags      []string `mapstructure:"network_tags"`
+	NetworkTagsMatch string   `mapstructure:"network_tags_match"`
+	ServiceAccounts  []string `mapstructure:"service_accounts"`
+	BexprFilter      string   `mapstructure:"bexpr_filter"`
+	MetadataKeys     []string `mapstructure:"metadata_keys"`
+	InstanceTemplate string   `mapstructure:"instance_template"`
+	Instances        []string `mapstructure:"instances"`
+
+	NetworkEndpointGroup      string   `mapstructure:"network_endpoint_group"`
+	BackendService            string   `mapstructure:"backend_service"`
+	BackendServiceRegion      string   `mapstructure:"backend_service_region"`
+	HealthyOnly               bool     `mapstructure:"healthy_only"`
+	GKECluster                string   `mapstructure:"gke_cluster"`
+	GKENodePool               string   `mapstructure:"gke_node_pool"`
+	DataprocCluster           string   `mapstructure:"dataproc_cluster"`
+	Roles                     []string `mapstructure:"roles"`
+	ServiceDirectory          string   `mapstructure:"service_directory"`
+	FirewallRule              string   `mapstructure:"firewall_rule"`
+	FirewallRuleInterfaceOnly bool     `mapstructure:"firewall_rule_interface_only"`
+	Tags                      []string `mapstructure:"tags"`
+
+	Statuses           []string `mapstructure:"statuses"`
+	OnMissingAddress   string   `mapstructure:"on_missing_address"`
+	ProvisioningModels []string `mapstructure:"provisioning_models"`
+	ExcludePreemptible bool     `mapstructure:"exclude_preemptible"`
+
+	RequireGuestAttribute string `mapstructure:"require_guest_attribute"`
+
+	// MinUptime, MinAge, MaxAge and MaintenanceHorizon are read with
+	// values.GetDurationValue rather than decoded by mapstructure.
+	MinUptime          time.Duration `mapstructure:"-"`
+	MinAge             time.Duration `mapstructure:"-"`
+	MaxAge             time.Duration `mapstructure:"-"`
+	MaintenanceHorizon time.Duration `mapstructure:"-"`
+
+	// Labels, ExcludeLabels and Metadata are read with
+	// values.GetMapStringString rather than decoded by mapstructure.
+	Labels        map[string]string `mapstructure:"-"`
+	ExcludeLabels map[string]string `mapstructure:"-"`
+	Metadata      map[string]string `mapstructure:"-"`
+	Annotations   map[string]string `mapstructure:"-"`
+}
+
+// includesAddress reports whether an address of the given kind and
+// family should be reported for a host. An empty selection includes
+// every kind or family.
+func (a *SetAttributes) includesAddress(kind, family string) bool {
+	if a == nil {
+		return true
+	}
+	if len(a.AddressKinds) > 0 && !stringInSlice(a.AddressKinds, kind) {
+		return false
+	}
+	if len(a.AddressFamilies) > 0 && !stringInSlice(a.AddressFamilies, family) {
+		return false
+	}
+	return true
+}
+
+// includesInterface reports whether the addresses of a network
+// interface should be reported for a host, based on the network and
+// subnetwork the set is restricted to.
+func (a *SetAttributes) includesInterface(iface *computepb.NetworkInterface) bool {
+	if a == nil {
+		return true
+	}
+	if a.Network != "" && !resourceMatches(iface.GetNetwork(), a.Network) {
+		return false
+	}
+	if a.Subnetwork != "" && !resourceMatches(iface.GetSubnetwork(), a.Subnetwork) {
+		return false
+	}
+	return true
 }
 
 func getSetAttributes(in *structpb.Struct) (*SetAttributes, error) {
@@ -62,10 +196,10 @@ func getSetAttributes(in *structpb.Struct) (*SetAttributes, error) {
 	badFields := make(map[string]string)
 	unknownFields := values.StructFields(in)
 
-	delete(unknownFields, ConstListInstancesFilter)
-	delete(unknownFields, ConstInstanceGroup)
-
 	for a := range unknownFields {
+		if _, ok := allowedSetFields[a]; ok {
+			continue
+		}
 		badFields[fmt.Sprintf("attributes.%s", a)] = "unrecognized field"
 	}
 	if len(badFields) > 0 {
@@ -88,37 +222,426 @@ func getSetAttributes(in *structpb.Struct) (*SetAttributes, error) {
 			inMap[ConstInstanceGroup] = string(instanceGroupValue)
 		}
 	}
+	normalizeSliceFields(inMap)
 
 	if err := mapstructure.Decode(inMap, &setAttrs); err != nil {
 		return nil, status.Errorf(codes.InvalidArgument, "error decoding set attributes: %s", err)
 	}
 
+	var err error
+	setAttrs.Labels, err = values.GetMapStringString(in, ConstLabels, false)
+	if err != nil {
+		badFields[fmt.Sprintf("attributes.%s", ConstLabels)] = err.Error()
+	}
+	setAttrs.ExcludeLabels, err = values.GetMapStringString(in, ConstExcludeLabels, false)
+	if err != nil {
+		badFields[fmt.Sprintf("attributes.%s", ConstExcludeLabels)] = err.Error()
+	}
+	setAttrs.Metadata, err = values.GetMapStringString(in, ConstMetadata, false)
+	if err != nil {
+		badFields[fmt.Sprintf("attributes.%s", ConstMetadata)] = err.Error()
+	}
+	setAttrs.Annotations, err = values.GetMapStringString(in, ConstAnnotations, false)
+	if err != nil {
+		badFields[fmt.Sprintf("attributes.%s", ConstAnnotations)] = err.Error()
+	}
+	setAttrs.MinUptime, err = values.GetDurationValue(in, ConstMinUptime)
+	if err != nil {
+		badFields[fmt.Sprintf("attributes.%s", ConstMinUptime)] = err.Error()
+	}
+	setAttrs.MinAge, err = values.GetDurationValue(in, ConstMinAge)
+	if err != nil {
+		badFields[fmt.Sprintf("attributes.%s", ConstMinAge)] = err.Error()
+	}
+	setAttrs.MaxAge, err = values.GetDurationValue(in, ConstMaxAge)
+	if err != nil {
+		badFields[fmt.Sprintf("attributes.%s", ConstMaxAge)] = err.Error()
+	}
+	setAttrs.MaintenanceHorizon, err = values.GetDurationValue(in, ConstMaintenanceHorizon)
+	if err != nil {
+		badFields[fmt.Sprintf("attributes.%s", ConstMaintenanceHorizon)] = err.Error()
+	}
+	if len(badFields) > 0 {
+		return nil, errors.InvalidArgumentError("Error in the attributes provided", badFields)
+	}
+
 	return &setAttrs, nil
 }
 
-func buildListInstancesRequest(attributes *SetAttributes, catalog *CatalogAttributes) *computepb.ListInstancesRequest {
-	request := &computepb.ListInstancesRequest{
-		Project: catalog.Project,
-		Zone:    catalog.Zone,
+// parseGuestAttribute splits a guest attribute requirement of the form
+// namespace/key=value into the variable key and the expected value.
+func parseGuestAttribute(s string) (string, string, error) {
+	key, value, ok := strings.Cut(s, "=")
+	if !ok {
+		return "", "", fmt.Errorf("must be of the form namespace/key=value")
+	}
+	namespace, name, ok := strings.Cut(key, "/")
+	if !ok || namespace == "" || name == "" || strings.Contains(name, "/") {
+		return "", "", fmt.Errorf("must be of the form namespace/key=value")
+	}
+	return key, value, nil
+}
+
+// normalizeSliceFields wraps scalar values of the slice set fields
+// into a single element slice so they can be decoded by mapstructure.
+func normalizeSliceFields(inMap map[string]any) {
+	for _, k := range sliceSetFields {
+		if raw, ok := inMap[k]; ok {
+			switch val := raw.(type) {
+			case string:
+				inMap[k] = []any{val}
+			}
+		}
 	}
+}
 
-	if len(attributes.Filter) > 1 {
-		request.Filter = &attributes.Filter
+// instanceFilters returns the filter expressions selecting the instances
+// of the set, each combined with the label selectors. The scalar filter
+// attribute is used when no list of filters is set.
+func instanceFilters(attributes *SetAttributes) []string {
+	expressions := attributes.Filters
+	if len(expressions) == 0 {
+		expressions = []string{attributes.Filter}
 	}
 
-	return request
+	labels := labelFilter(attributes.Labels, attributes.ExcludeLabels)
+	combined := make([]string, 0, len(expressions))
+	for _, expression := range expressions {
+		// Filters using eq or ne cannot be combined with the label
+		// comparisons, their labels are only checked by selectInstances.
+		if regexpFilter(expression) {
+			combined = append(combined, expression)
+			continue
+		}
+		combined = append(combined, combineFilters(labels, expression))
+	}
+	return combined
+}
+
+// buildListInstancesRequests returns one request per filter of the set.
+// The results of the requests are unioned, so the filters have OR
+// semantics.
+func buildListInstancesRequests(attributes *SetAttributes, catalog *CatalogAttributes) []*computepb.ListInstancesRequest {
+	expressions := instanceFilters(attributes)
+	statuses := instanceStatuses(attributes, catalog)
+	requests := make([]*computepb.ListInstancesRequest, 0, len(expressions))
+	for _, expression := range expressions {
+		expression := expression
+		// Filters using eq or ne cannot be combined with the status
+		// comparison, their statuses are only checked by selectByStatus
+		// after listing.
+		if !regexpFilter(expression) {
+			expression = combineFilters(anyOfFilter("status", statuses), expression)
+		}
+		request := &computepb.ListInstancesRequest{
+			Project: catalog.Project,
+			Zone:    catalog.Zone,
+		}
+
+		if len(expression) > 1 {
+			request.Filter = &expression
+		}
+
+		requests = append(requests, request)
+	}
+	return requests
+}
+
+// buildInstancesByNameRequests returns the requests listing the
+// instances named in the set. Instances are grouped by project and zone,
+// and each request lists up to instanceNameBatchSize instances by name.
+func buildInstancesByNameRequests(attributes *SetAttributes, catalog *CatalogAttributes) []*computepb.ListInstancesRequest {
+	type location struct {
+		project, zone string
+	}
+	var locations []location
+	names := make(map[location][]string)
+	for _, ref := range attributes.Instances {
+		project, zone, name := instanceReference(ref, catalog)
+		loc := location{project: project, zone: zone}
+		if _, ok := names[loc]; !ok {
+			locations = append(locations, loc)
+		}
+		names[loc] = append(names[loc], name)
+	}
+
+	labels := labelFilter(attributes.Labels, attributes.ExcludeLabels)
+	var requests []*computepb.ListInstancesRequest
+	for _, loc := range locations {
+		batch := names[loc]
+		for len(batch) > 0 {
+			n := min(len(batch), instanceNameBatchSize)
+			expression := combineFilters(labels, anyOfFilter("name", batch[:n]))
+			requests = append(requests, &computepb.ListInstancesRequest{
+				Project: loc.project,
+				Zone:    loc.zone,
+				Filter:  &expression,
+			})
+			batch = batch[n:]
+		}
+	}
+	return requests
+}
+
+// anyOfFilter returns a filter expression matching instances where the
+// field has any of the values.
+func anyOfFilter(field string, values []string) string {
+	expressions := make([]string, 0, len(values))
+	for _, v := range values {
+		expressions = append(expressions, fmt.Sprintf("(%s = %s)", field, quoteFilterValue(v)))
+	}
+	return strings.Join(expressions, " OR ")
+}
+
+// instanceStatuses returns the instance statuses included in the set,
+// the statuses of the set or else the catalog default. A nil result
+// includes instances in every status.
+func instanceStatuses(attributes *SetAttributes, catalog *CatalogAttributes) []string {
+	statuses := attributes.Statuses
+	if len(statuses) == 0 {
+		statuses = catalog.DefaultStatuses
+	}
+	if stringInSlice(statuses, ConstStatusAll) {
+		return nil
+	}
+	return statuses
+}
+
+// missingAddressPolicy returns what to do with instances of the set
+// without any address, the policy of the set or else the catalog default.
+func missingAddressPolicy(attributes *SetAttributes, catalog *CatalogAttributes) string {
+	if attributes.OnMissingAddress != "" {
+		return attributes.OnMissingAddress
+	}
+	if catalog.OnMissingAddress != "" {
+		return catalog.OnMissingAddress
+	}
+	return ConstMissingAddressSkip
+}
+
+// validateMissingAddressPolicy checks that the policy is include, skip
+// or error.
+func validateMissingAddressPolicy(policy string) error {
+	if _, ok := allowedMissingAddressPolicies[policy]; !ok {
+		return fmt.Errorf("must be %s, %s or %s", ConstMissingAddressInclude, ConstMissingAddressSkip, ConstMissingAddressError)
+	}
+	return nil
+}
+
+// validateStatuses checks that the statuses are Compute Engine instance
+// statuses, or ALL.
+func validateStatuses(statuses []string) error {
+	for _, s := range statuses {
+		if s == ConstStatusAll {
+			continue
+		}
+		if _, ok := computepb.Instance_Status_value[s]; !ok || s == computepb.Instance_UNDEFINED_STATUS.String() {
+			return fmt.Errorf("unknown instance status %q", s)
+		}
+	}
+	return nil
+}
+
+// instanceReference returns the project, zone and name of an instance
+// given by name or self-link. Bare names are in the catalog project and
+// zone.
+func instanceReference(ref string, catalog *CatalogAttributes) (string, string, string) {
+	return zonalReference(ref, "instances", catalog.Project, catalog.Zone)
+}
+
+// zonalReference returns the project, zone and name of a zonal resource
+// in the collection given by name or self-link. The project and zone
+// default to the given ones when the reference does not include them.
+func zonalReference(ref, collection, defaultProject, defaultZone string) (string, string, string) {
+	if !strings.Contains(ref, "/") {
+		return defaultProject, defaultZone, ref
+	}
+	project, zone, name := parseZonalPath(ref, collection)
+	if project == "" {
+		project = defaultProject
+	}
+	if zone == "" {
+		zone = defaultZone
+	}
+	return project, zone, name
+}
+
+// missingInstanceReferences returns the instance references of the set
+// that are not among the listed instances.
+func missingInstanceReferences(attributes *SetAttributes, catalog *CatalogAttributes, instances []*computepb.Instance) []string {
+	found := make(map[string]struct{}, len(instances))
+	for _, instance := range instances {
+		project, zone, name := instanceLocation(instance)
+		found[path.Join(project, zone, name)] = struct{}{}
+	}
+
+	var missing []string
+	for _, ref := range attributes.Instances {
+		project, zone, name := instanceReference(ref, catalog)
+		if _, ok := found[path.Join(project, zone, name)]; !ok {
+			missing = append(missing, ref)
+		}
+	}
+	return missing
+}
+
+// labelFilter compiles the label selectors into a Compute API filter
+// expression. Label keys are validated before they get here, values
+// are quoted.
+func labelFilter(labels, excludeLabels map[string]string) string {
+	var expressions []string
+	for _, k := range sortedKeys(labels) {
+		expressions = append(expressions, fmt.Sprintf("labels.%s = %s", k, quoteFilterValue(labels[k])))
+	}
+	for _, k := range sortedKeys(excludeLabels) {
+		expressions = append(expressions, fmt.Sprintf("labels.%s != %s", k, quoteFilterValue(excludeLabels[k])))
+	}
+	return combineFilters(expressions...)
+}
+
+// regexpFilter reports whether the filter expression uses the eq or ne
+// regular expression comparators, which the Compute API does not accept
+// together with the comparisons generated by the plugin.
+func regexpFilter(expression string) bool {
+	f, err := filter.Parse(expression)
+	return err == nil && f.UsesRegexp()
+}
+
+// combineFilters joins the non-empty filter expressions with AND,
+// wrapping each in parentheses so that OR expressions in user supplied
+// filters keep their meaning.
+func combineFilters(filters ...string) string {
+	var nonEmpty []string
+	for _, f := range filters {
+		if strings.TrimSpace(f) == "" {
+			continue
+		}
+		nonEmpty = append(nonEmpty, f)
+	}
+	if len(nonEmpty) == 1 {
+		return nonEmpty[0]
+	}
+	for i, f := range nonEmpty {
+		nonEmpty[i] = fmt.Sprintf("(%s)", f)
+	}
+	return strings.Join(nonEmpty, " AND ")
+}
+
+// quoteFilterValue returns the value as a double quoted string literal
+// for use in a filter expression.
+func quoteFilterValue(v string) string {
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, `"`, `\"`)
+	return `"` + v + `"`
+}
+
+// validateLabels checks the keys and values of a label selector against
+// the Compute Engine label requirements and returns an error describing
+// the first violation found.
+func validateLabels(labels map[string]string) error {
+	for _, k := range sortedKeys(labels) {
+		if !labelKeyRegexp.MatchString(k) {
+			return fmt.Errorf("invalid label key %q, keys must start with a lowercase letter and contain at most 63 lowercase letters, digits, underscores or dashes", k)
+		}
+		if !labelValueRegexp.MatchString(labels[k]) {
+			return fmt.Errorf("invalid value %q for label %q, values must contain at most 63 lowercase letters, digits, underscores or dashes", labels[k], k)
+		}
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
 }
 
 func buildListInstanceGroupsRequest(attributes *SetAttributes, catalog *CatalogAttributes) *computepb.ListInstancesInstanceGroupsRequest {
+	instanceState := instanceGroupState(attributes, catalog)
 	request := &computepb.ListInstancesInstanceGroupsRequest{
 		InstanceGroup: attributes.InstanceGroup,
 		Project:       catalog.Project,
 		Zone:          catalog.Zone,
+		InstanceGroupsListInstancesRequestResource: &computepb.InstanceGroupsListInstancesRequest{
+			InstanceState: &instanceState,
+		},
+	}
+
+	return request
+}
+
+// buildListNetworkEndpointsRequest returns the request listing the
+// endpoints of the network endpoint group of the set, given by name or
+// URL.
+func buildListNetworkEndpointsRequest(attributes *SetAttributes, catalog *CatalogAttributes) *computepb.ListNetworkEndpointsNetworkEndpointGroupsRequest {
+	project, zone, name := zonalReference(attributes.NetworkEndpointGroup, "networkEndpointGroups", catalog.Project, catalog.Zone)
+	return &computepb.ListNetworkEndpointsNetworkEndpointGroupsRequest{
+		NetworkEndpointGroup: name,
+		Project:              project,
+		Zone:                 zone,
+		NetworkEndpointGroupsListEndpointsRequestResource: &computepb.NetworkEndpointGroupsListEndpointsRequest{},
 	}
+}
 
-	if len(attributes.Filter) > 1 {
-		request.Filter = &attributes.Filter
+// instanceGroupState returns the instance state to list the members of
+// instance groups in. The instance group API can only list running
+// instances or all of them, other statuses are selected after listing.
+func instanceGroupState(attributes *SetAttributes, catalog *CatalogAttributes) string {
+	if statuses := instanceStatuses(attributes, catalog); len(statuses) == 1 && statuses[0] == ConstStatusRunning {
+		return computepb.InstanceGroupsListInstancesRequest_RUNNING.String()
 	}
+	return computepb.InstanceGroupsListInstancesRequest_ALL.String()
+}
 
-	return request
+// backendServiceReference returns the project, region and name of the
+// backend service of the set, given by name or URL. The region is empty
+// for global backend services.
+func backendServiceReference(attributes *SetAttributes, catalog *CatalogAttributes) (string, string, string) {
+	if !strings.Contains(attributes.BackendService, "/") {
+		return catalog.Project, attributes.BackendServiceRegion, attributes.BackendService
+	}
+	segments := resourceSegments(attributes.BackendService)
+	project := segments["projects"]
+	if project == "" {
+		project = catalog.Project
+	}
+	region := segments["regions"]
+	if region == "" {
+		region = attributes.BackendServiceRegion
+	}
+	return project, region, segments["backendServices"]
+}
+
+// buildInstanceGroupFilters parses the filters of a set selecting an
+// instance group. The filter of an instance group ListInstances request
+// applies to the membership records rather than the instances, so these
+// filters are evaluated by the plugin after the members are resolved.
+// An instance matching any of the filters is selected.
+func buildInstanceGroupFilters(attributes *SetAttributes) ([]*filter.Filter, error) {
+	var filters []*filter.Filter
+	for _, expression := range instanceFilters(attributes) {
+		if strings.TrimSpace(expression) == "" {
+			continue
+		}
+		f, err := filter.Parse(expression)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing filter %q: %w", expression, err)
+		}
+		filters = append(filters, f)
+	}
+	return filters, nil
+}
+
+// validateFilter parses a filter expression and checks that the fields
+// it references exist on instances, so that mistakes are reported when
+// the set is created rather than on the next sync.
+func validateFilter(expression string) error {
+	f, err := filter.Parse(expression)
+	if err != nil {
+		return err
+	}
+	return f.Validate((&computepb.Instance{}).ProtoReflect().Descriptor())
 }
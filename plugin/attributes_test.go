@@ -4,11 +4,16 @@
 package plugin
 
 import (
+	"fmt"
 	"testing"
+	"time"
 
+	computepb "cloud.google.com/go/compute/apiv1/computepb"
+	cred "github.com/joatmon08/boundary-plugin-google/internal/credential"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/structpb"
 )
 
@@ -38,6 +43,75 @@ func TestGetCatalogAttributes(t *testing.T) {
 			},
 			expectedErrContains: "attributes.bar: unrecognized field, attributes.foo: unrecognized field",
 		},
+		{
+			name: "default statuses",
+			in: &structpb.Struct{
+				Fields: map[string]*structpb.Value{
+					"project": structpb.NewStringValue("test-12345"),
+					"zone":    structpb.NewStringValue("us-central-1a"),
+				},
+			},
+			expected: &CatalogAttributes{
+				CredentialAttributes: &cred.CredentialAttributes{
+					Project: "test-12345",
+					Zone:    "us-central-1a",
+				},
+				DefaultStatuses:  []string{"RUNNING"},
+				OnMissingAddress: "skip",
+			},
+		},
+		{
+			name: "custom default statuses",
+			in: &structpb.Struct{
+				Fields: map[string]*structpb.Value{
+					"project":             structpb.NewStringValue("test-12345"),
+					"zone":                structpb.NewStringValue("us-central-1a"),
+					ConstDefaultStatuses:  structpb.NewListValue(&structpb.ListValue{Values: []*structpb.Value{structpb.NewStringValue("RUNNING"), structpb.NewStringValue("STAGING")}}),
+					ConstOnMissingAddress: structpb.NewStringValue("include"),
+				},
+			},
+			expected: &CatalogAttributes{
+				CredentialAttributes: &cred.CredentialAttributes{
+					Project: "test-12345",
+					Zone:    "us-central-1a",
+				},
+				DefaultStatuses:  []string{"RUNNING", "STAGING"},
+				OnMissingAddress: "include",
+			},
+		},
+		{
+			name: "unknown default status",
+			in: &structpb.Struct{
+				Fields: map[string]*structpb.Value{
+					"project":            structpb.NewStringValue("test-12345"),
+					"zone":               structpb.NewStringValue("us-central-1a"),
+					ConstDefaultStatuses: structpb.NewStringValue("running"),
+				},
+			},
+			expectedErrContains: "attributes.default_statuses: unknown instance status \"running\"",
+		},
+		{
+			name: "empty default statuses",
+			in: &structpb.Struct{
+				Fields: map[string]*structpb.Value{
+					"project":            structpb.NewStringValue("test-12345"),
+					"zone":               structpb.NewStringValue("us-central-1a"),
+					ConstDefaultStatuses: structpb.NewListValue(&structpb.ListValue{}),
+				},
+			},
+			expectedErrContains: "attributes.default_statuses: must not be empty",
+		},
+		{
+			name: "unknown missing address policy",
+			in: &structpb.Struct{
+				Fields: map[string]*structpb.Value{
+					"project":             structpb.NewStringValue("test-12345"),
+					"zone":                structpb.NewStringValue("us-central-1a"),
+					ConstOnMissingAddress: structpb.NewStringValue("drop"),
+				},
+			},
+			expectedErrContains: "attributes.on_missing_address: must be include, skip or error",
+		},
 	}
 
 	for _, tc := range cases {
@@ -90,6 +164,114 @@ func TestGetSetAttributes(t *testing.T) {
 				InstanceGroup: "test",
 			},
 		},
+		{
+			name: "scalar address kind",
+			in: map[string]any{
+				ConstAddressKinds: ConstAddressKindExternal,
+			},
+			expected: &SetAttributes{
+				AddressKinds: []string{ConstAddressKindExternal},
+			},
+		},
+		{
+			name: "address kinds and families",
+			in: map[string]any{
+				ConstAddressKinds:    []any{ConstAddressKindInternal, ConstAddressKindAlias},
+				ConstAddressFamilies: []any{ConstAddressFamilyIPv6},
+			},
+			expected: &SetAttributes{
+				AddressKinds:    []string{ConstAddressKindInternal, ConstAddressKindAlias},
+				AddressFamilies: []string{ConstAddressFamilyIPv6},
+			},
+		},
+		{
+			name: "labels",
+			in: map[string]any{
+				ConstLabels: map[string]any{
+					"env": "prod",
+				},
+				ConstExcludeLabels: map[string]any{
+					"team": "payments",
+				},
+			},
+			expected: &SetAttributes{
+				Labels: map[string]string{
+					"env": "prod",
+				},
+				ExcludeLabels: map[string]string{
+					"team": "payments",
+				},
+			},
+		},
+		{
+			name: "labels with non-string value",
+			in: map[string]any{
+				ConstLabels: map[string]any{
+					"env": true,
+				},
+			},
+			expectedErrContains: "attributes.labels: unexpected type for value in map[\"env\"]",
+		},
+		{
+			name: "example filters",
+			in: map[string]any{
+				ConstListInstancesFilters: []any{"name=web-*", "labels.role=bastion"},
+			},
+			expected: &SetAttributes{
+				Filters: []string{"name=web-*", "labels.role=bastion"},
+			},
+		},
+		{
+			name: "metadata",
+			in: map[string]any{
+				ConstMetadata: map[string]any{
+					"boundary-role": "db-*",
+				},
+				ConstMetadataKeys: "enable-oslogin",
+			},
+			expected: &SetAttributes{
+				Metadata: map[string]string{
+					"boundary-role": "db-*",
+				},
+				MetadataKeys: []string{"enable-oslogin"},
+			},
+		},
+		{
+			name: "durations",
+			in: map[string]any{
+				ConstMinUptime: "15m",
+				ConstMinAge:    "90d",
+				ConstMaxAge:    float64(3600),
+			},
+			expected: &SetAttributes{
+				MinUptime: 15 * time.Minute,
+				MinAge:    90 * 24 * time.Hour,
+				MaxAge:    time.Hour,
+			},
+		},
+		{
+			name: "scalar instances",
+			in: map[string]any{
+				ConstInstances: "web-1",
+			},
+			expected: &SetAttributes{
+				Instances: []string{"web-1"},
+			},
+		},
+		{
+			name: "invalid duration",
+			in: map[string]any{
+				ConstMinUptime: "soon",
+			},
+			expectedErrContains: "attributes.min_uptime: could not parse duration in value \"min_uptime\"",
+		},
+		{
+			name: "negative duration",
+			in: map[string]any{
+				ConstMaxAge: "-1h",
+			},
+			expectedErrContains: "attributes.max_age: duration in value \"max_age\" cannot be negative",
+		},
 		{
 			name: "unknown fields",
 			in: map[string]any{
@@ -121,3 +303,256 @@ func TestGetSetAttributes(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildListInstancesRequestFilter(t *testing.T) {
+	catalog := testCatalog()
+
+	cases := []struct {
+		name           string
+		attributes     *SetAttributes
+		expectedFilter string
+	}{
+		{
+			name:       "no filter",
+			attributes: &SetAttributes{},
+		},
+		{
+			name: "filter",
+			attributes: &SetAttributes{
+				Filter: "status=RUNNING",
+			},
+			expectedFilter: "status=RUNNING",
+		},
+		{
+			name: "labels",
+			attributes: &SetAttributes{
+				Labels: map[string]string{
+					"team": "payments",
+					"env":  "prod",
+				},
+			},
+			expectedFilter: `(labels.env = "prod") AND (labels.team = "payments")`,
+		},
+		{
+			name: "labels, exclude labels and filter",
+			attributes: &SetAttributes{
+				Filter: "name = web-1 OR name = web-2",
+				Labels: map[string]string{
+					"env": "prod",
+				},
+				ExcludeLabels: map[string]string{
+					"canary": "",
+				},
+			},
+			expectedFilter: `((labels.env = "prod") AND (labels.canary != "")) AND (name = web-1 OR name = web-2)`,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			actual := buildListInstancesRequests(tc.attributes, catalog)
+			require.Len(actual, 1)
+			require.Equal(catalog.Project, actual[0].GetProject())
+			require.Equal(catalog.Zone, actual[0].GetZone())
+			require.Equal(tc.expectedFilter, actual[0].GetFilter())
+		})
+	}
+}
+
+func TestQuoteFilterValue(t *testing.T) {
+	require.Equal(t, `"prod"`, quoteFilterValue("prod"))
+	require.Equal(t, `"a \"quoted\" \\ value"`, quoteFilterValue(`a "quoted" \ value`))
+}
+
+func TestValidateLabels(t *testing.T) {
+	require := require.New(t)
+
+	require.NoError(validateLabels(map[string]string{"env": "prod", "cost_center": "", "team-1": "ä-1"}))
+	require.ErrorContains(validateLabels(map[string]string{"Env": "prod"}), `invalid label key "Env"`)
+	require.ErrorContains(validateLabels(map[string]string{"1env": "prod"}), `invalid label key "1env"`)
+	require.ErrorContains(validateLabels(map[string]string{"env": "Prod Stage"}), `invalid value "Prod Stage" for label "env"`)
+}
+
+func TestBuildListInstancesRequests(t *testing.T) {
+	require := require.New(t)
+	catalog := testCatalog()
+
+	requests := buildListInstancesRequests(&SetAttributes{Filter: "status=RUNNING"}, catalog)
+	require.Len(requests, 1)
+	require.Equal("status=RUNNING", requests[0].GetFilter())
+
+	requests = buildListInstancesRequests(&SetAttributes{
+		Filters: []string{"name=web-*", "labels.role=bastion"},
+		Labels: map[string]string{
+			"env": "prod",
+		},
+	}, catalog)
+	require.Len(requests, 2)
+	require.Equal(`(labels.env = "prod") AND (name=web-*)`, requests[0].GetFilter())
+	require.Equal(`(labels.env = "prod") AND (labels.role=bastion)`, requests[1].GetFilter())
+
+	// Regular expression filters are sent as-is, labels are checked
+	// after listing.
+	requests = buildListInstancesRequests(&SetAttributes{
+		Filters: []string{"name eq web-.*", "name=db-*"},
+		Labels: map[string]string{
+			"env": "prod",
+		},
+		ExcludeLabels: map[string]string{
+			"tier": "canary",
+		},
+	}, catalog)
+	require.Len(requests, 2)
+	require.Equal("name eq web-.*", requests[0].GetFilter())
+	require.Equal(`((labels.env = "prod") AND (labels.tier != "canary")) AND (name=db-*)`, requests[1].GetFilter())
+}
+
+func TestMissingAddressPolicy(t *testing.T) {
+	require := require.New(t)
+
+	require.Equal("skip", missingAddressPolicy(&SetAttributes{}, &CatalogAttributes{}))
+	require.Equal("error", missingAddressPolicy(&SetAttributes{}, &CatalogAttributes{OnMissingAddress: "error"}))
+	require.Equal("include", missingAddressPolicy(&SetAttributes{OnMissingAddress: "include"}, &CatalogAttributes{OnMissingAddress: "error"}))
+}
+
+func TestBuildRequestsWithStatuses(t *testing.T) {
+	require := require.New(t)
+	catalog := testCatalog()
+	catalog.DefaultStatuses = []string{"RUNNING"}
+
+	requests := buildListInstancesRequests(&SetAttributes{Filter: "name = web-*"}, catalog)
+	require.Len(requests, 1)
+	require.Equal(`((status = "RUNNING")) AND (name = web-*)`, requests[0].GetFilter())
+
+	// Regular expression filters are sent as-is, statuses are checked
+	// after listing.
+	requests = buildListInstancesRequests(&SetAttributes{Filter: "name eq web-.*"}, catalog)
+	require.Len(requests, 1)
+	require.Equal("name eq web-.*", requests[0].GetFilter())
+
+	requests = buildListInstancesRequests(&SetAttributes{Statuses: []string{"RUNNING", "STOPPED"}}, catalog)
+	require.Len(requests, 1)
+	require.Equal(`(status = "RUNNING") OR (status = "STOPPED")`, requests[0].GetFilter())
+
+	requests = buildListInstancesRequests(&SetAttributes{Statuses: []string{"ALL"}}, catalog)
+	require.Len(requests, 1)
+	require.Nil(requests[0].Filter)
+
+	request := buildListInstanceGroupsRequest(&SetAttributes{InstanceGroup: "test"}, catalog)
+	require.Equal("RUNNING", request.GetInstanceGroupsListInstancesRequestResource().GetInstanceState())
+
+	request = buildListInstanceGroupsRequest(&SetAttributes{InstanceGroup: "test", Statuses: []string{"STOPPED"}}, catalog)
+	require.Equal("ALL", request.GetInstanceGroupsListInstancesRequestResource().GetInstanceState())
+}
+
+func TestBuildInstancesByNameRequests(t *testing.T) {
+	require := require.New(t)
+	catalog := testCatalog()
+
+	requests := buildInstancesByNameRequests(&SetAttributes{
+		Instances: []string{
+			"web-1",
+			"https://www.googleapis.com/compute/v1/projects/other-project/zones/europe-west1-b/instances/db-1",
+			"projects/test-project/zones/us-central1-a/instances/web-2",
+		},
+		Labels: map[string]string{
+			"env": "prod",
+		},
+	}, catalog)
+	require.Len(requests, 2)
+	require.Equal("test-project", requests[0].GetProject())
+	require.Equal("us-central1-a", requests[0].GetZone())
+	require.Equal(`(labels.env = "prod") AND ((name = "web-1") OR (name = "web-2"))`, requests[0].GetFilter())
+	require.Equal("other-project", requests[1].GetProject())
+	require.Equal("europe-west1-b", requests[1].GetZone())
+	require.Equal(`(labels.env = "prod") AND ((name = "db-1"))`, requests[1].GetFilter())
+
+	var names []string
+	for i := 0; i < instanceNameBatchSize+1; i++ {
+		names = append(names, fmt.Sprintf("web-%d", i))
+	}
+	requests = buildInstancesByNameRequests(&SetAttributes{Instances: names}, catalog)
+	require.Len(requests, 2)
+	require.Equal(`(name = "web-50")`, requests[1].GetFilter())
+}
+
+func TestBuildListNetworkEndpointsRequest(t *testing.T) {
+	require := require.New(t)
+	catalog := testCatalog()
+
+	request := buildListNetworkEndpointsRequest(&SetAttributes{NetworkEndpointGroup: "web-neg"}, catalog)
+	require.Equal("web-neg", request.GetNetworkEndpointGroup())
+	require.Equal("test-project", request.GetProject())
+	require.Equal("us-central1-a", request.GetZone())
+
+	request = buildListNetworkEndpointsRequest(&SetAttributes{
+		NetworkEndpointGroup: "https://www.googleapis.com/compute/v1/projects/other-project/zones/europe-west1-b/networkEndpointGroups/db-neg",
+	}, catalog)
+	require.Equal("db-neg", request.GetNetworkEndpointGroup())
+	require.Equal("other-project", request.GetProject())
+	require.Equal("europe-west1-b", request.GetZone())
+}
+
+func TestMissingInstanceReferences(t *testing.T) {
+	catalog := testCatalog()
+	attributes := &SetAttributes{
+		Instances: []string{
+			"web-1",
+			"web-2",
+			"projects/other-project/zones/europe-west1-b/instances/web-1",
+		},
+	}
+	instances := []*computepb.Instance{
+		{
+			SelfLink: proto.String("https://www.googleapis.com/compute/v1/projects/test-project/zones/us-central1-a/instances/web-1"),
+		},
+	}
+
+	require.Equal(t, []string{"web-2", "projects/other-project/zones/europe-west1-b/instances/web-1"}, missingInstanceReferences(attributes, catalog, instances))
+}
+
+func TestBuildInstanceGroupFilters(t *testing.T) {
+	require := require.New(t)
+
+	filters, err := buildInstanceGroupFilters(&SetAttributes{InstanceGroup: "test"})
+	require.NoError(err)
+	require.Empty(filters)
+
+	filters, err = buildInstanceGroupFilters(&SetAttributes{
+		InstanceGroup: "test",
+		Filters:       []string{"status = RUNNING", "name = bastion-*"},
+		Labels: map[string]string{
+			"tier": "web",
+		},
+	})
+	require.NoError(err)
+	require.Len(filters, 2)
+
+	_, err = buildInstanceGroupFilters(&SetAttributes{
+		InstanceGroup: "test",
+		Filter:        "status = (RUNNING",
+	})
+	require.ErrorContains(err, `error parsing filter "status = (RUNNING"`)
+}
+
+func TestParseGuestAttribute(t *testing.T) {
+	require := require.New(t)
+
+	key, value, err := parseGuestAttribute("boundary/ready=true")
+	require.NoError(err)
+	require.Equal("boundary/ready", key)
+	require.Equal("true", value)
+
+	key, value, err = parseGuestAttribute("boundary/ready=")
+	require.NoError(err)
+	require.Equal("boundary/ready", key)
+	require.Equal("", value)
+
+	for _, invalid := range []string{"boundary/ready", "ready=true", "/ready=true", "boundary/=true", "boundary/app/ready=true"} {
+		_, _, err = parseGuestAttribute(invalid)
+		require.Error(err, invalid)
+	}
+}
@@ -0,0 +1,196 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package plugin
+
+import (
+	computepb "cloud.google.com/go/compute/apiv1/computepb"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// getInstancesForBackendService returns the instances behind every
+// instance group and zonal network endpoint group backend of the backend
+// service of the set, and the endpoints of instances behind network
+// endpoint groups keyed by instance self-link. With healthy_only, only
+// instances the load balancer reports as healthy are returned.
+func (c *GoogleClient) getInstancesForBackendService(attributes *SetAttributes, catalog *CatalogAttributes) ([]*computepb.Instance, map[string][]*computepb.NetworkEndpoint, error) {
+	project, region, name := backendServiceReference(attributes, catalog)
+
+	var service *computepb.BackendService
+	var err error
+	if region == "" {
+		service, err = c.BackendServiceClient.Get(c.Context, &computepb.GetBackendServiceRequest{
+			BackendService: name,
+			Project:        project,
+		})
+	} else {
+		service, err = c.RegionBackendServiceClient.Get(c.Context, &computepb.GetRegionBackendServiceRequest{
+			BackendService: name,
+			Project:        project,
+			Region:         region,
+		})
+	}
+	if err != nil {
+		return nil, nil, status.Errorf(codes.InvalidArgument, "error getting backend service %s: %s", name, err)
+	}
+
+	instanceState := instanceGroupState(attributes, catalog)
+	var hosts []*computepb.Instance
+	endpoints := make(map[string][]*computepb.NetworkEndpoint)
+	seen := make(map[string]struct{})
+	for _, backend := range service.GetBackends() {
+		group := backend.GetGroup()
+		segments := resourceSegments(group)
+
+		var members []*computepb.Instance
+		var memberEndpoints map[string][]*computepb.NetworkEndpoint
+		switch {
+		case segments["networkEndpointGroups"] != "" && segments["zones"] != "":
+			members, memberEndpoints, err = c.getInstancesForNetworkEndpointGroup(&computepb.ListNetworkEndpointsNetworkEndpointGroupsRequest{
+				NetworkEndpointGroup: segments["networkEndpointGroups"],
+				Project:              segments["projects"],
+				Zone:                 segments["zones"],
+				NetworkEndpointGroupsListEndpointsRequestResource: &computepb.NetworkEndpointGroupsListEndpointsRequest{},
+			})
+		case segments["instanceGroups"] != "":
+			members, err = c.getInstancesForInstanceGroupURL(group, instanceState)
+		default:
+			// Serverless and internet network endpoint groups are not
+			// backed by instances.
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if attributes.HealthyOnly {
+			healthy, err := c.getHealthyInstances(project, region, name, group)
+			if err != nil {
+				return nil, nil, err
+			}
+			selected := members[:0]
+			for _, member := range members {
+				if _, ok := healthy[resourcePath(member.GetSelfLink())]; ok {
+					selected = append(selected, member)
+				}
+			}
+			members = selected
+		}
+
+		for _, member := range members {
+			if _, ok := seen[member.GetSelfLink()]; ok {
+				continue
+			}
+			seen[member.GetSelfLink()] = struct{}{}
+			hosts = append(hosts, member)
+			if memberEndpoints != nil {
+				endpoints[member.GetSelfLink()] = memberEndpoints[member.GetSelfLink()]
+			}
+		}
+	}
+	return hosts, endpoints, nil
+}
+
+// getInstancesForInstanceGroupURL returns the instances of a zonal or
+// regional instance group given by URL, listed in the instance state.
+// URLs of managed instance groups refer to the instance group of the
+// same name.
+func (c *GoogleClient) getInstancesForInstanceGroupURL(url, instanceState string) ([]*computepb.Instance, error) {
+	segments := resourceSegments(url)
+	name := segments["instanceGroups"]
+	if name == "" {
+		name = segments["instanceGroupManagers"]
+	}
+
+	switch {
+	case name != "" && segments["zones"] != "":
+		return c.getInstancesForInstanceGroup(&computepb.ListInstancesInstanceGroupsRequest{
+			InstanceGroup: name,
+			Project:       segments["projects"],
+			Zone:          segments["zones"],
+			InstanceGroupsListInstancesRequestResource: &computepb.InstanceGroupsListInstancesRequest{
+				InstanceState: &instanceState,
+			},
+		})
+	case name != "" && segments["regions"] != "":
+		return c.getInstancesForRegionInstanceGroup(&computepb.ListInstancesRegionInstanceGroupsRequest{
+			InstanceGroup: name,
+			Project:       segments["projects"],
+			Region:        segments["regions"],
+			RegionInstanceGroupsListInstancesRequestResource: &computepb.RegionInstanceGroupsListInstancesRequest{
+				InstanceState: &instanceState,
+			},
+		})
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unsupported instance group %s", url)
+	}
+}
+
+// getInstancesForRegionInstanceGroup returns the instances of a
+// regional managed instance group.
+func (c *GoogleClient) getInstancesForRegionInstanceGroup(request *computepb.ListInstancesRegionInstanceGroupsRequest) ([]*computepb.Instance, error) {
+	hosts := []*computepb.Instance{}
+	instances := c.RegionInstanceGroupClient.ListInstances(c.Context, request)
+
+	for {
+		resp, err := instances.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "error listing instances for region instance group %s: %s", request.InstanceGroup, err)
+		}
+
+		project, zone, name := zonalReference(resp.GetInstance(), "instances", request.Project, "")
+		instance, err := c.InstancesClient.Get(c.Context, &computepb.GetInstanceRequest{
+			Instance: name,
+			Project:  project,
+			Zone:     zone,
+		})
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "error getting instance %s for region instance group %s: %s", resp.GetInstance(), request.InstanceGroup, err)
+		}
+
+		hosts = append(hosts, instance)
+	}
+	return hosts, nil
+}
+
+// getHealthyInstances returns the relative resource paths of the
+// instances of a backend group that the backend service reports as
+// healthy.
+func (c *GoogleClient) getHealthyInstances(project, region, service, group string) (map[string]struct{}, error) {
+	reference := &computepb.ResourceGroupReference{
+		Group: &group,
+	}
+
+	var health *computepb.BackendServiceGroupHealth
+	var err error
+	if region == "" {
+		health, err = c.BackendServiceClient.GetHealth(c.Context, &computepb.GetHealthBackendServiceRequest{
+			BackendService:                 service,
+			Project:                        project,
+			ResourceGroupReferenceResource: reference,
+		})
+	} else {
+		health, err = c.RegionBackendServiceClient.GetHealth(c.Context, &computepb.GetHealthRegionBackendServiceRequest{
+			BackendService:                 service,
+			Project:                        project,
+			Region:                         region,
+			ResourceGroupReferenceResource: reference,
+		})
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "error getting health of backend %s for backend service %s: %s", group, service, err)
+	}
+
+	healthy := make(map[string]struct{})
+	for _, s := range health.GetHealthStatus() {
+		if s.GetHealthState() == ConstHealthStateHealthy {
+			healthy[resourcePath(s.GetInstance())] = struct{}{}
+		}
+	}
+	return healthy, nil
+}
@@ -0,0 +1,137 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package plugin
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newBackendServiceTestClient(t *testing.T) *GoogleClient {
+	return newTestClient(t, testRoutes{
+		"/compute/v1/projects/test-project/global/backendServices/web": serveJSON(`{"backends": [
+			{"group": "` + testComputeURL + `/zones/us-central1-a/instanceGroups/web-ig"},
+			{"group": "` + testComputeURL + `/zones/us-central1-b/networkEndpointGroups/web-neg"},
+			{"group": "` + testComputeURL + `/global/networkEndpointGroups/serverless"}
+		]}`),
+		"/compute/v1/projects/test-project/global/backendServices/web/getHealth": func(w http.ResponseWriter, r *http.Request) {
+			var body struct {
+				Group string `json:"group"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			if strings.HasSuffix(body.Group, "/web-ig") {
+				w.Write([]byte(`{"healthStatus": [
+					{"instance": "` + testComputeURL + `/zones/us-central1-a/instances/web-1", "healthState": "HEALTHY"},
+					{"instance": "` + testComputeURL + `/zones/us-central1-a/instances/web-2", "healthState": "UNHEALTHY"}
+				]}`))
+				return
+			}
+			w.Write([]byte(`{"healthStatus": [
+				{"instance": "` + testComputeURL + `/zones/us-central1-b/instances/web-3", "healthState": "HEALTHY"}
+			]}`))
+		},
+		"/compute/v1/projects/test-project/regions/us-central1/backendServices/internal": serveJSON(`{"backends": [
+			{"group": "` + testComputeURL + `/regions/us-central1/instanceGroups/regional-ig"}
+		]}`),
+		"/compute/v1/projects/test-project/zones/us-central1-a/instanceGroups/web-ig/listInstances": serveJSON(`{"items": [
+			{"instance": "` + testComputeURL + `/zones/us-central1-a/instances/web-1"},
+			{"instance": "` + testComputeURL + `/zones/us-central1-a/instances/web-2"}
+		]}`),
+		"/compute/v1/projects/test-project/zones/us-central1-b/networkEndpointGroups/web-neg/listNetworkEndpoints": serveJSON(`{"items": [
+			{"networkEndpoint": {"instance": "web-3", "ipAddress": "10.0.1.3", "port": 8443}}
+		]}`),
+		"/compute/v1/projects/test-project/regions/us-central1/instanceGroups/regional-ig/listInstances": serveJSON(`{"items": [
+			{"instance": "` + testComputeURL + `/zones/us-central1-c/instances/web-4"}
+		]}`),
+		"/compute/v1/projects/test-project/zones/*/instances/*": serveInstance,
+	})
+}
+
+func TestGetInstancesForBackendService(t *testing.T) {
+	gclient := newBackendServiceTestClient(t)
+	catalog := testCatalog()
+
+	cases := []struct {
+		name              string
+		attributes        *SetAttributes
+		expected          []string
+		expectedEndpoints int
+	}{
+		{
+			name: "global",
+			attributes: &SetAttributes{
+				BackendService: "web",
+			},
+			expected:          []string{"web-1", "web-2", "web-3"},
+			expectedEndpoints: 1,
+		},
+		{
+			name: "global healthy only",
+			attributes: &SetAttributes{
+				BackendService: testComputeURL + "/global/backendServices/web",
+				HealthyOnly:    true,
+			},
+			expected:          []string{"web-1", "web-3"},
+			expectedEndpoints: 1,
+		},
+		{
+			name: "regional",
+			attributes: &SetAttributes{
+				BackendService:       "internal",
+				BackendServiceRegion: "us-central1",
+			},
+			expected: []string{"web-4"},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			instances, endpoints, err := gclient.getInstancesForBackendService(tc.attributes, catalog)
+			require.NoError(err)
+
+			var names []string
+			for _, instance := range instances {
+				names = append(names, instance.GetName())
+			}
+			require.Equal(tc.expected, names)
+			require.Len(endpoints, tc.expectedEndpoints)
+		})
+	}
+}
+
+func TestBackendServiceReference(t *testing.T) {
+	require := require.New(t)
+	catalog := testCatalog()
+
+	project, region, name := backendServiceReference(&SetAttributes{BackendService: "web"}, catalog)
+	require.Equal([]string{"test-project", "", "web"}, []string{project, region, name})
+
+	project, region, name = backendServiceReference(&SetAttributes{BackendService: "web", BackendServiceRegion: "us-east1"}, catalog)
+	require.Equal([]string{"test-project", "us-east1", "web"}, []string{project, region, name})
+
+	project, region, name = backendServiceReference(&SetAttributes{BackendService: "projects/other-project/regions/europe-west1/backendServices/db"}, catalog)
+	require.Equal([]string{"other-project", "europe-west1", "db"}, []string{project, region, name})
+}
+
+func TestResourceSegments(t *testing.T) {
+	require.Equal(t, map[string]string{
+		"projects":       "test-project",
+		"zones":          "us-central1-a",
+		"instanceGroups": "web-ig",
+	}, resourceSegments(testComputeURL+"/zones/us-central1-a/instanceGroups/web-ig"))
+	require.Equal(t, map[string]string{
+		"projects":        "test-project",
+		"backendServices": "web",
+	}, resourceSegments("projects/test-project/global/backendServices/web"))
+}
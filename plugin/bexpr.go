@@ -0,0 +1,110 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package plugin
+
+import (
+	"path"
+
+	computepb "cloud.google.com/go/compute/apiv1/computepb"
+	"github.com/hashicorp/go-bexpr"
+)
+
+// instanceView is the view of an instance that bexpr filters are
+// evaluated against. Resource URLs are reduced to their names. Only the
+// keys of the instance metadata are exposed, since values may hold
+// secrets such as startup scripts or SSH keys.
+type instanceView struct {
+	Name              string                 `bexpr:"name"`
+	Id                uint64                 `bexpr:"id"`
+	Labels            map[string]string      `bexpr:"labels"`
+	NetworkTags       []string               `bexpr:"network_tags"`
+	Zone              string                 `bexpr:"zone"`
+	MachineType       string                 `bexpr:"machine_type"`
+	Status            string                 `bexpr:"status"`
+	ServiceAccounts   []string               `bexpr:"service_accounts"`
+	MetadataKeys      []string               `bexpr:"metadata_keys"`
+	Networks          []string               `bexpr:"networks"`
+	Subnetworks       []string               `bexpr:"subnetworks"`
+	NetworkInterfaces []networkInterfaceView `bexpr:"network_interfaces"`
+}
+
+// networkInterfaceView is the view of a network interface of an
+// instance that bexpr filters are evaluated against.
+type networkInterfaceView struct {
+	Name        string   `bexpr:"name"`
+	Network     string   `bexpr:"network"`
+	Subnetwork  string   `bexpr:"subnetwork"`
+	InternalIp  string   `bexpr:"internal_ip"`
+	ExternalIps []string `bexpr:"external_ips"`
+}
+
+func newInstanceView(instance *computepb.Instance) *instanceView {
+	view := &instanceView{
+		Name:        instance.GetName(),
+		Id:          instance.GetId(),
+		Labels:      instance.GetLabels(),
+		NetworkTags: instance.GetTags().GetItems(),
+		Zone:        path.Base(instance.GetZone()),
+		MachineType: path.Base(instance.GetMachineType()),
+		Status:      instance.GetStatus(),
+	}
+	if view.Labels == nil {
+		view.Labels = map[string]string{}
+	}
+
+	for _, sa := range instance.GetServiceAccounts() {
+		view.ServiceAccounts = append(view.ServiceAccounts, sa.GetEmail())
+	}
+	for _, item := range instance.GetMetadata().GetItems() {
+		view.MetadataKeys = append(view.MetadataKeys, item.GetKey())
+	}
+
+	for _, iface := range instance.GetNetworkInterfaces() {
+		ifaceView := networkInterfaceView{
+			Name:       iface.GetName(),
+			Network:    path.Base(iface.GetNetwork()),
+			Subnetwork: path.Base(iface.GetSubnetwork()),
+			InternalIp: iface.GetNetworkIP(),
+		}
+		for _, external := range iface.GetAccessConfigs() {
+			ifaceView.ExternalIps = appendDistinct(ifaceView.ExternalIps, external.NatIP)
+		}
+		for _, external := range iface.GetIpv6AccessConfigs() {
+			ifaceView.ExternalIps = appendDistinct(ifaceView.ExternalIps, external.ExternalIpv6)
+		}
+
+		view.Networks = appendDistinct(view.Networks, &ifaceView.Network)
+		view.Subnetworks = appendDistinct(view.Subnetworks, &ifaceView.Subnetwork)
+		view.NetworkInterfaces = append(view.NetworkInterfaces, ifaceView)
+	}
+
+	return view
+}
+
+// newBexprEvaluator creates an evaluator for a bexpr filter. Selectors
+// that cannot be resolved, such as a missing label, evaluate to an empty
+// string rather than failing the evaluation.
+func newBexprEvaluator(expression string) (*bexpr.Evaluator, error) {
+	return bexpr.CreateEvaluator(expression, bexpr.WithUnknownValue(""))
+}
+
+// bexprInstances returns the instances matching the bexpr evaluator.
+// All instances are returned when the evaluator is nil.
+func bexprInstances(instances []*computepb.Instance, eval *bexpr.Evaluator) ([]*computepb.Instance, error) {
+	if eval == nil {
+		return instances, nil
+	}
+
+	selected := make([]*computepb.Instance, 0, len(instances))
+	for _, instance := range instances {
+		ok, err := eval.Evaluate(newInstanceView(instance))
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			selected = append(selected, instance)
+		}
+	}
+	return selected, nil
+}
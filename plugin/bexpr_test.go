@@ -0,0 +1,109 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package plugin
+
+import (
+	"testing"
+
+	"cloud.google.com/go/compute/apiv1/computepb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestBexprInstances(t *testing.T) {
+	web := &computepb.Instance{
+		Name:        proto.String("web"),
+		Status:      proto.String("RUNNING"),
+		Zone:        proto.String("https://www.googleapis.com/compute/v1/projects/test-project/zones/us-central1-a"),
+		MachineType: proto.String("https://www.googleapis.com/compute/v1/projects/test-project/zones/us-central1-a/machineTypes/e2-medium"),
+		Labels: map[string]string{
+			"tier": "web",
+		},
+		Tags: &computepb.Tags{
+			Items: []string{"http-server"},
+		},
+		Metadata: &computepb.Metadata{
+			Items: []*computepb.Items{
+				{
+					Key:   proto.String("boundary-role"),
+					Value: proto.String("web-admin"),
+				},
+			},
+		},
+		NetworkInterfaces: []*computepb.NetworkInterface{
+			{
+				Name:       proto.String("nic0"),
+				Network:    proto.String("https://www.googleapis.com/compute/v1/projects/host-project/global/networks/shared-vpc"),
+				Subnetwork: proto.String("https://www.googleapis.com/compute/v1/projects/host-project/regions/us-central1/subnetworks/data"),
+				NetworkIP:  proto.String("10.0.0.2"),
+			},
+		},
+	}
+	db := &computepb.Instance{
+		Name:        proto.String("db"),
+		Status:      proto.String("TERMINATED"),
+		MachineType: proto.String("https://www.googleapis.com/compute/v1/projects/test-project/zones/us-central1-a/machineTypes/n2-highmem-4"),
+		Labels: map[string]string{
+			"tier": "db",
+		},
+	}
+	instances := []*computepb.Instance{web, db}
+
+	cases := []struct {
+		name        string
+		expression  string
+		expected    []*computepb.Instance
+		expectedErr string
+	}{
+		{
+			name:       "label",
+			expression: `labels.tier == "web"`,
+			expected:   []*computepb.Instance{web},
+		},
+		{
+			name:       "missing label",
+			expression: `labels.env != "prod"`,
+			expected:   []*computepb.Instance{web, db},
+		},
+		{
+			name:       "machine type and status",
+			expression: `machine_type matches "^n2-" or status == "RUNNING"`,
+			expected:   []*computepb.Instance{web, db},
+		},
+		{
+			name:       "zone",
+			expression: `zone == "us-central1-a"`,
+			expected:   []*computepb.Instance{web},
+		},
+		{
+			name:       "metadata key",
+			expression: `"boundary-role" in metadata_keys`,
+			expected:   []*computepb.Instance{web},
+		},
+		{
+			name:       "subnetwork",
+			expression: `"data" in subnetworks and "http-server" in network_tags`,
+			expected:   []*computepb.Instance{web},
+		},
+		{
+			name:       "network interface",
+			expression: `any network_interfaces as nic { nic.network == "shared-vpc" and nic.internal_ip == "10.0.0.2" }`,
+			expected:   []*computepb.Instance{web},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			eval, err := newBexprEvaluator(tc.expression)
+			require.NoError(err)
+
+			actual, err := bexprInstances(instances, eval)
+			require.NoError(err)
+			require.Equal(tc.expected, actual)
+		})
+	}
+}
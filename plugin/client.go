@@ -6,26 +6,241 @@ package plugin
 import (
 	"context"
 	"errors"
+	"net/http"
+	"net/netip"
 	"path"
+	"strings"
+	"sync"
 
 	compute "cloud.google.com/go/compute/apiv1"
 	computepb "cloud.google.com/go/compute/apiv1/computepb"
 	pb "github.com/hashicorp/boundary/sdk/pbs/plugin"
+	cloudresourcemanager "google.golang.org/api/cloudresourcemanager/v3"
+	container "google.golang.org/api/container/v1"
+	dataproc "google.golang.org/api/dataproc/v1"
+	"google.golang.org/api/googleapi"
 	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+	servicedirectory "google.golang.org/api/servicedirectory/v1"
+	sqladmin "google.golang.org/api/sqladmin/v1"
+	tpu "google.golang.org/api/tpu/v2"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
 )
 
 const (
 	NumberMaxResults = uint32(100)
+
+	// guestAttributeConcurrency is the number of guest attribute lookups
+	// run at the same time.
+	guestAttributeConcurrency = 10
+
+	// effectiveTagsConcurrency is the number of effective tag lookups
+	// run at the same time.
+	effectiveTagsConcurrency = 10
+
+	// instanceNameBatchSize is the number of instances listed by name in
+	// a single request, keeping the filter expression within the length
+	// accepted by the API.
+	instanceNameBatchSize = 50
 )
 
 type GoogleClient struct {
-	InstancesClient     *compute.InstancesClient
-	InstanceGroupClient *compute.InstanceGroupsClient
-	Context             context.Context
-	Project             string
-	Zone                string
+	InstancesClient            *compute.InstancesClient
+	InstanceGroupClient        *compute.InstanceGroupsClient
+	NetworkEndpointGroupClient *compute.NetworkEndpointGroupsClient
+	RegionInstanceGroupClient  *compute.RegionInstanceGroupsClient
+	BackendServiceClient       *compute.BackendServicesClient
+	RegionBackendServiceClient *compute.RegionBackendServicesClient
+	FirewallClient             *compute.FirewallsClient
+	ContainerService           *container.Service
+	SQLAdminService            *sqladmin.Service
+	DataprocService            *dataproc.Service
+	TPUService                 *tpu.Service
+	ServiceDirectoryService    *servicedirectory.APIService
+	Context                    context.Context
+	Project                    string
+	Zone                       string
+
+	// TagEndpoint is the Resource Manager endpoint for effective tags of
+	// zonal resources, with {zone} in place of the zone. It defaults to
+	// the zonal endpoint of the API. TagServiceOptions are added to the
+	// options of the services created for each zone.
+	TagEndpoint       string
+	TagServiceOptions []option.ClientOption
+
+	// guestAttributes caches guest attribute lookups for the lifetime
+	// of the client, which is a single sync.
+	guestAttributesMu sync.Mutex
+	guestAttributes   map[string]guestAttribute
+
+	// tagServices holds a Resource Manager service per zone, and
+	// effectiveTags caches effective tag lookups for the lifetime of the
+	// client.
+	tagsMu        sync.Mutex
+	tagServices   map[string]*cloudresourcemanager.Service
+	effectiveTags map[string][]*cloudresourcemanager.EffectiveTag
+}
+
+// requiredClients records the API clients needed to list the hosts of
+// the sets of a sync, so that only those clients are created.
+type requiredClients struct {
+	instances             bool
+	instanceGroups        bool
+	regionInstanceGroups  bool
+	networkEndpointGroups bool
+	backendServices       bool
+	firewalls             bool
+	container             bool
+	sqlAdmin              bool
+	dataproc              bool
+	tpu                   bool
+	serviceDirectory      bool
+}
+
+// add records the clients needed to list the hosts of the set.
+func (r *requiredClients) add(attributes *SetAttributes) {
+	switch {
+	case attributes.Type == ConstSetTypeCloudSQL:
+		r.sqlAdmin = true
+		return
+	case attributes.Type == ConstSetTypeTPU:
+		r.tpu = true
+		return
+	case attributes.ServiceDirectory != "":
+		r.serviceDirectory = true
+		return
+	}
+
+	r.instances = true
+	switch {
+	case attributes.GKECluster != "":
+		r.container = true
+		r.instanceGroups = true
+		r.regionInstanceGroups = true
+	case attributes.DataprocCluster != "":
+		r.dataproc = true
+	case attributes.BackendService != "":
+		r.backendServices = true
+		r.networkEndpointGroups = true
+		r.instanceGroups = true
+		r.regionInstanceGroups = true
+	case attributes.NetworkEndpointGroup != "":
+		r.networkEndpointGroups = true
+	case attributes.InstanceGroup != "":
+		r.instanceGroups = true
+	}
+	if attributes.FirewallRule != "" {
+		r.firewalls = true
+	}
+}
+
+// newGoogleClient returns a client with the required API clients. The
+// client must be closed after use.
+func newGoogleClient(ctx context.Context, required requiredClients) (*GoogleClient, error) {
+	c := &GoogleClient{Context: ctx}
+	err := c.createClients(ctx, required)
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *GoogleClient) createClients(ctx context.Context, required requiredClients) error {
+	var err error
+	if required.instances {
+		if c.InstancesClient, err = compute.NewInstancesRESTClient(ctx); err != nil {
+			return status.Errorf(codes.InvalidArgument, "error creating NewInstancesRESTClient: %s", err)
+		}
+	}
+	if required.instanceGroups {
+		if c.InstanceGroupClient, err = compute.NewInstanceGroupsRESTClient(ctx); err != nil {
+			return status.Errorf(codes.InvalidArgument, "error creating NewInstanceGroupsRESTClient: %s", err)
+		}
+	}
+	if required.regionInstanceGroups {
+		if c.RegionInstanceGroupClient, err = compute.NewRegionInstanceGroupsRESTClient(ctx); err != nil {
+			return status.Errorf(codes.InvalidArgument, "error creating NewRegionInstanceGroupsRESTClient: %s", err)
+		}
+	}
+	if required.networkEndpointGroups {
+		if c.NetworkEndpointGroupClient, err = compute.NewNetworkEndpointGroupsRESTClient(ctx); err != nil {
+			return status.Errorf(codes.InvalidArgument, "error creating NewNetworkEndpointGroupsRESTClient: %s", err)
+		}
+	}
+	if required.backendServices {
+		if c.BackendServiceClient, err = compute.NewBackendServicesRESTClient(ctx); err != nil {
+			return status.Errorf(codes.InvalidArgument, "error creating NewBackendServicesRESTClient: %s", err)
+		}
+		if c.RegionBackendServiceClient, err = compute.NewRegionBackendServicesRESTClient(ctx); err != nil {
+			return status.Errorf(codes.InvalidArgument, "error creating NewRegionBackendServicesRESTClient: %s", err)
+		}
+	}
+	if required.firewalls {
+		if c.FirewallClient, err = compute.NewFirewallsRESTClient(ctx); err != nil {
+			return status.Errorf(codes.InvalidArgument, "error creating NewFirewallsRESTClient: %s", err)
+		}
+	}
+	if required.container {
+		if c.ContainerService, err = container.NewService(ctx); err != nil {
+			return status.Errorf(codes.InvalidArgument, "error creating container service: %s", err)
+		}
+	}
+	if required.sqlAdmin {
+		if c.SQLAdminService, err = sqladmin.NewService(ctx); err != nil {
+			return status.Errorf(codes.InvalidArgument, "error creating SQL Admin service: %s", err)
+		}
+	}
+	if required.dataproc {
+		if c.DataprocService, err = dataproc.NewService(ctx); err != nil {
+			return status.Errorf(codes.InvalidArgument, "error creating Dataproc service: %s", err)
+		}
+	}
+	if required.tpu {
+		if c.TPUService, err = tpu.NewService(ctx); err != nil {
+			return status.Errorf(codes.InvalidArgument, "error creating TPU service: %s", err)
+		}
+	}
+	if required.serviceDirectory {
+		if c.ServiceDirectoryService, err = servicedirectory.NewService(ctx); err != nil {
+			return status.Errorf(codes.InvalidArgument, "error creating Service Directory service: %s", err)
+		}
+	}
+	return nil
+}
+
+// Close closes the Compute REST clients. The services of the other APIs
+// use the default HTTP transport and hold nothing to close.
+func (c *GoogleClient) Close() {
+	if c.InstancesClient != nil {
+		c.InstancesClient.Close()
+	}
+	if c.InstanceGroupClient != nil {
+		c.InstanceGroupClient.Close()
+	}
+	if c.RegionInstanceGroupClient != nil {
+		c.RegionInstanceGroupClient.Close()
+	}
+	if c.NetworkEndpointGroupClient != nil {
+		c.NetworkEndpointGroupClient.Close()
+	}
+	if c.BackendServiceClient != nil {
+		c.BackendServiceClient.Close()
+	}
+	if c.RegionBackendServiceClient != nil {
+		c.RegionBackendServiceClient.Close()
+	}
+	if c.FirewallClient != nil {
+		c.FirewallClient.Close()
+	}
+}
+
+// guestAttribute is the result of a guest attribute lookup.
+type guestAttribute struct {
+	value string
+	found bool
 }
 
 func (c *GoogleClient) getInstances(request *computepb.ListInstancesRequest) ([]*computepb.Instance, error) {
@@ -71,7 +286,184 @@ func (c *GoogleClient) getInstancesForInstanceGroup(request *computepb.ListInsta
 	return hosts, nil
 }
 
-func instanceToHost(instance *computepb.Instance) (*pb.ListHostsResponseHost, error) {
+// getInstancesForNetworkEndpointGroup returns the instances behind the
+// endpoints of a network endpoint group, and their endpoints keyed by
+// instance self-link. Endpoints not backed by an instance are ignored.
+func (c *GoogleClient) getInstancesForNetworkEndpointGroup(request *computepb.ListNetworkEndpointsNetworkEndpointGroupsRequest) ([]*computepb.Instance, map[string][]*computepb.NetworkEndpoint, error) {
+	type instanceKey struct {
+		project, zone, name string
+	}
+	var keys []instanceKey
+	endpointsByKey := make(map[instanceKey][]*computepb.NetworkEndpoint)
+
+	it := c.NetworkEndpointGroupClient.ListNetworkEndpoints(c.Context, request)
+	for {
+		resp, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, nil, status.Errorf(codes.InvalidArgument, "error listing endpoints for network endpoint group %s: %s", request.NetworkEndpointGroup, err)
+		}
+
+		endpoint := resp.GetNetworkEndpoint()
+		if endpoint.GetInstance() == "" {
+			continue
+		}
+		project, zone, name := zonalReference(endpoint.GetInstance(), "instances", request.Project, request.Zone)
+		key := instanceKey{project: project, zone: zone, name: name}
+		if _, ok := endpointsByKey[key]; !ok {
+			keys = append(keys, key)
+		}
+		endpointsByKey[key] = append(endpointsByKey[key], endpoint)
+	}
+
+	hosts := make([]*computepb.Instance, 0, len(keys))
+	endpoints := make(map[string][]*computepb.NetworkEndpoint, len(keys))
+	for _, key := range keys {
+		instance, err := c.InstancesClient.Get(c.Context, &computepb.GetInstanceRequest{
+			Instance: key.name,
+			Project:  key.project,
+			Zone:     key.zone,
+		})
+		if err != nil {
+			return nil, nil, status.Errorf(codes.InvalidArgument, "error getting instance %s for network endpoint group %s: %s", key.name, request.NetworkEndpointGroup, err)
+		}
+
+		hosts = append(hosts, instance)
+		endpoints[instance.GetSelfLink()] = endpointsByKey[key]
+	}
+	return hosts, endpoints, nil
+}
+
+// missingInstances returns the instance references, given by name or
+// self-link, that do not exist.
+func (c *GoogleClient) missingInstances(refs []string, catalog *CatalogAttributes) ([]string, error) {
+	var missing []string
+	for _, ref := range refs {
+		project, zone, name := instanceReference(ref, catalog)
+		_, err := c.InstancesClient.Get(c.Context, &computepb.GetInstanceRequest{
+			Instance: name,
+			Project:  project,
+			Zone:     zone,
+		})
+		switch {
+		case err == nil:
+		case isHTTPStatus(err, http.StatusNotFound):
+			missing = append(missing, ref)
+		default:
+			return nil, status.Errorf(codes.InvalidArgument, "error getting instance %s: %s", ref, err)
+		}
+	}
+	return missing, nil
+}
+
+// selectByGuestAttribute returns the instances whose guest attribute at
+// the variable key has the wanted value.
+func (c *GoogleClient) selectByGuestAttribute(instances []*computepb.Instance, key, want string) ([]*computepb.Instance, error) {
+	return selectConcurrently(instances, guestAttributeConcurrency, func(instance *computepb.Instance) (bool, error) {
+		attr, err := c.getGuestAttribute(instance, key)
+		if err != nil {
+			return false, err
+		}
+		return attr.found && attr.value == want, nil
+	})
+}
+
+// getGuestAttribute returns the guest attribute of the instance at the
+// variable key. Instances without the attribute, or without guest
+// attributes enabled, are reported as not found.
+func (c *GoogleClient) getGuestAttribute(instance *computepb.Instance, key string) (guestAttribute, error) {
+	cacheKey := instance.GetSelfLink() + "|" + key
+
+	c.guestAttributesMu.Lock()
+	attr, ok := c.guestAttributes[cacheKey]
+	c.guestAttributesMu.Unlock()
+	if ok {
+		return attr, nil
+	}
+
+	project, zone, name := instanceLocation(instance)
+	resp, err := c.InstancesClient.GetGuestAttributes(c.Context, &computepb.GetGuestAttributesInstanceRequest{
+		Instance:    name,
+		Project:     project,
+		Zone:        zone,
+		VariableKey: &key,
+	})
+	switch {
+	case err == nil:
+		attr = guestAttribute{value: resp.GetVariableValue(), found: true}
+	case isHTTPStatus(err, http.StatusNotFound, http.StatusBadRequest):
+		attr = guestAttribute{}
+	default:
+		return guestAttribute{}, status.Errorf(codes.InvalidArgument, "error getting guest attribute %s for instance %s: %s", key, instance.GetSelfLink(), err)
+	}
+
+	c.guestAttributesMu.Lock()
+	if c.guestAttributes == nil {
+		c.guestAttributes = make(map[string]guestAttribute)
+	}
+	c.guestAttributes[cacheKey] = attr
+	c.guestAttributesMu.Unlock()
+
+	return attr, nil
+}
+
+// instanceLocation returns the project, zone and name of an instance
+// from its self-link.
+func instanceLocation(instance *computepb.Instance) (project, zone, name string) {
+	return parseInstancePath(instance.GetSelfLink())
+}
+
+// parseInstancePath returns the project, zone and name of an instance
+// from its self-link or relative resource path.
+func parseInstancePath(ref string) (project, zone, name string) {
+	return parseZonalPath(ref, "instances")
+}
+
+// parseZonalPath returns the project, zone and name of a zonal resource
+// in the collection, e.g. instances, from its self-link or relative
+// resource path.
+func parseZonalPath(ref, collection string) (project, zone, name string) {
+	segments := resourceSegments(ref)
+	return segments["projects"], segments["zones"], segments[collection]
+}
+
+// resourceSegments returns the segments of a resource self-link or
+// relative resource path keyed by collection, e.g. projects/p/zones/z
+// gives {"projects": "p", "zones": "z"}. The global scope segment is
+// skipped.
+func resourceSegments(ref string) map[string]string {
+	var parts []string
+	for _, part := range strings.Split(resourcePath(ref), "/") {
+		if part != "global" {
+			parts = append(parts, part)
+		}
+	}
+
+	segments := make(map[string]string)
+	for i := 0; i+1 < len(parts); i += 2 {
+		segments[parts[i]] = parts[i+1]
+	}
+	return segments
+}
+
+// isHTTPStatus reports whether the error is a Google API error with one
+// of the HTTP status codes.
+func isHTTPStatus(err error, statusCodes ...int) bool {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	for _, code := range statusCodes {
+		if apiErr.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+func instanceToHost(instance *computepb.Instance, attributes *SetAttributes) (*pb.ListHostsResponseHost, error) {
 	if instance.GetSelfLink() == "" {
 		return nil, errors.New("response integrity error: missing instance self-link")
 	}
@@ -81,25 +473,137 @@ func instanceToHost(instance *computepb.Instance) (*pb.ListHostsResponseHost, er
 	result.ExternalId = instance.GetSelfLink()
 	result.ExternalName = instance.GetName()
 
+	appendAddresses := func(kind string, addrs ...*string) {
+		for _, addr := range addrs {
+			if addr == nil || !attributes.includesAddress(kind, addressFamily(*addr)) {
+				continue
+			}
+			result.IpAddresses = appendDistinct(result.IpAddresses, addr)
+		}
+	}
+
 	// Now go through all of the interfaces and log the IP address of
 	// every interface.
 	for _, iface := range instance.GetNetworkInterfaces() {
+		if !attributes.includesInterface(iface) {
+			continue
+		}
+
 		// Populate default IP addresses/DNS name similar to how we do
 		// for the entire instance.
-		result.IpAddresses = appendDistinct(result.IpAddresses, iface.NetworkIP)
+		appendAddresses(ConstAddressKindInternal, iface.NetworkIP)
 
 		for _, external := range iface.AccessConfigs {
-			result.IpAddresses = appendDistinct(result.IpAddresses, external.NatIP)
+			appendAddresses(ConstAddressKindExternal, external.NatIP)
 		}
 
 		// Add the IPv6 addresses.
-		result.IpAddresses = appendDistinct(result.IpAddresses, iface.Ipv6Address)
+		appendAddresses(ConstAddressKindInternal, iface.Ipv6Address)
+
+		for _, external := range iface.Ipv6AccessConfigs {
+			appendAddresses(ConstAddressKindExternal, external.ExternalIpv6)
+		}
+
+		// Alias IP ranges are only reported when they hold a single
+		// address, larger ranges cannot be used to reach the host.
+		for _, alias := range iface.AliasIpRanges {
+			if addr, ok := aliasAddress(alias.GetIpCidrRange()); ok {
+				appendAddresses(ConstAddressKindAlias, &addr)
+			}
+		}
 	}
 
 	// Done
 	return result, nil
 }
 
+// applyNetworkEndpoints makes the endpoint addresses of the instance the
+// preferred addresses of the host and exposes the port of the first
+// endpoint with one as a host attribute. Endpoint addresses are selected
+// by the address kinds, families and network of the set like the other
+// addresses of the instance.
+func applyNetworkEndpoints(host *pb.ListHostsResponseHost, instance *computepb.Instance, endpoints []*computepb.NetworkEndpoint, attributes *SetAttributes) {
+	var addresses []string
+	var port int32
+	for _, endpoint := range endpoints {
+		if endpointAddressIncluded(instance, endpoint.GetIpAddress(), attributes) {
+			addresses = appendDistinct(addresses, endpoint.IpAddress)
+		}
+		if port == 0 {
+			port = endpoint.GetPort()
+		}
+	}
+	for _, addr := range host.IpAddresses {
+		addresses = appendDistinct(addresses, &addr)
+	}
+	host.IpAddresses = addresses
+
+	if port > 0 {
+		host.Attributes = &structpb.Struct{
+			Fields: map[string]*structpb.Value{
+				ConstHostAttributePort: structpb.NewNumberValue(float64(port)),
+			},
+		}
+	}
+}
+
+// addressFamily returns the address family of an IP address.
+func addressFamily(addr string) string {
+	if strings.Contains(addr, ":") {
+		return ConstAddressFamilyIPv6
+	}
+	return ConstAddressFamilyIPv4
+}
+
+// aliasAddress returns the address of an alias IP range if the range
+// holds exactly one address.
+func aliasAddress(cidr string) (string, bool) {
+	if addr, err := netip.ParseAddr(cidr); err == nil {
+		return addr.String(), true
+	}
+	prefix, err := netip.ParsePrefix(cidr)
+	if err != nil || !prefix.IsSingleIP() {
+		return "", false
+	}
+	return prefix.Addr().String(), true
+}
+
+// endpointAddressIncluded reports whether a network endpoint address of
+// the instance should be reported for a host. Endpoints use the primary
+// internal address or an alias address of an interface, the interface
+// holding the address must be included by the set.
+func endpointAddressIncluded(instance *computepb.Instance, addr string, attributes *SetAttributes) bool {
+	if addr == "" {
+		return false
+	}
+	family := addressFamily(addr)
+	for _, iface := range instance.GetNetworkInterfaces() {
+		if iface.GetNetworkIP() == addr || iface.GetIpv6Address() == addr {
+			return attributes.includesInterface(iface) && attributes.includesAddress(ConstAddressKindInternal, family)
+		}
+		for _, alias := range iface.GetAliasIpRanges() {
+			if aliasContains(alias.GetIpCidrRange(), addr) {
+				return attributes.includesInterface(iface) && attributes.includesAddress(ConstAddressKindAlias, family)
+			}
+		}
+	}
+	return attributes.includesAddress(ConstAddressKindInternal, family)
+}
+
+// aliasContains reports whether the alias IP range, given as a CIDR range
+// or a single address, contains the address.
+func aliasContains(cidr, addr string) bool {
+	ip, err := netip.ParseAddr(addr)
+	if err != nil {
+		return false
+	}
+	if single, err := netip.ParseAddr(cidr); err == nil {
+		return single == ip
+	}
+	prefix, err := netip.ParsePrefix(cidr)
+	return err == nil && prefix.Contains(ip)
+}
+
 // appendDistinct will append the elements to the slice
 // if an element is not nil, empty, and does not exist in slice.
 func appendDistinct(slice []string, elems ...*string) []string {
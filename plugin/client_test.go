@@ -4,11 +4,15 @@
 package plugin
 
 import (
+	"net/http"
+	"strings"
+	"sync/atomic"
 	"testing"
 
 	"cloud.google.com/go/compute/apiv1/computepb"
 	pb "github.com/hashicorp/boundary/sdk/pbs/plugin"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
 )
 
 func TestInstanceToHost(t *testing.T) {
@@ -18,10 +22,42 @@ func TestInstanceToHost(t *testing.T) {
 	examplePublicIp := "1.1.1.1"
 	examplePublicIp2 := "1.1.1.2"
 	exampleIPv6 := "some::fake::address"
+	exampleExternalIPv6 := "2600:1900::1"
+	exampleAliasRange := "10.0.1.5/32"
+	exampleAliasIp := "10.0.1.5"
+	exampleAliasWideRange := "10.0.2.0/24"
+	exampleManagementNetwork := "https://www.googleapis.com/compute/v1/projects/host-project/global/networks/management"
+	exampleDataNetwork := "https://www.googleapis.com/compute/v1/projects/host-project/global/networks/data"
+
+	dualStackInterfaces := []*computepb.NetworkInterface{
+		{
+			NetworkIP:   &examplePrivateIp,
+			Ipv6Address: &exampleIPv6,
+			AccessConfigs: []*computepb.AccessConfig{
+				{
+					NatIP: &examplePublicIp,
+				},
+			},
+			Ipv6AccessConfigs: []*computepb.AccessConfig{
+				{
+					ExternalIpv6: &exampleExternalIPv6,
+				},
+			},
+			AliasIpRanges: []*computepb.AliasIpRange{
+				{
+					IpCidrRange: &exampleAliasRange,
+				},
+				{
+					IpCidrRange: &exampleAliasWideRange,
+				},
+			},
+		},
+	}
 
 	cases := []struct {
 		name        string
 		instance    *computepb.Instance
+		attributes  *SetAttributes
 		expected    *pb.ListHostsResponseHost
 		expectedErr string
 	}{
@@ -111,13 +147,81 @@ func TestInstanceToHost(t *testing.T) {
 				IpAddresses: []string{examplePrivateIp, exampleIPv6},
 			},
 		},
+		{
+			name: "good, external IPv6 and alias IP addresses",
+			instance: &computepb.Instance{
+				SelfLink:          &exampleId,
+				NetworkInterfaces: dualStackInterfaces,
+			},
+			expected: &pb.ListHostsResponseHost{
+				ExternalId:  exampleId,
+				IpAddresses: []string{examplePrivateIp, examplePublicIp, exampleIPv6, exampleExternalIPv6, exampleAliasIp},
+			},
+		},
+		{
+			name: "good, only external addresses",
+			instance: &computepb.Instance{
+				SelfLink:          &exampleId,
+				NetworkInterfaces: dualStackInterfaces,
+			},
+			attributes: &SetAttributes{
+				AddressKinds: []string{ConstAddressKindExternal},
+			},
+			expected: &pb.ListHostsResponseHost{
+				ExternalId:  exampleId,
+				IpAddresses: []string{examplePublicIp, exampleExternalIPv6},
+			},
+		},
+		{
+			name: "good, only internal and alias IPv4 addresses",
+			instance: &computepb.Instance{
+				SelfLink:          &exampleId,
+				NetworkInterfaces: dualStackInterfaces,
+			},
+			attributes: &SetAttributes{
+				AddressKinds:    []string{ConstAddressKindInternal, ConstAddressKindAlias},
+				AddressFamilies: []string{ConstAddressFamilyIPv4},
+			},
+			expected: &pb.ListHostsResponseHost{
+				ExternalId:  exampleId,
+				IpAddresses: []string{examplePrivateIp, exampleAliasIp},
+			},
+		},
+		{
+			name: "good, only addresses of the selected network",
+			instance: &computepb.Instance{
+				SelfLink: &exampleId,
+				NetworkInterfaces: []*computepb.NetworkInterface{
+					{
+						Network:   &exampleManagementNetwork,
+						NetworkIP: &examplePrivateIp,
+					},
+					{
+						Network:   &exampleDataNetwork,
+						NetworkIP: &examplePrivateIp2,
+						AccessConfigs: []*computepb.AccessConfig{
+							{
+								NatIP: &examplePublicIp2,
+							},
+						},
+					},
+				},
+			},
+			attributes: &SetAttributes{
+				Network: "data",
+			},
+			expected: &pb.ListHostsResponseHost{
+				ExternalId:  exampleId,
+				IpAddresses: []string{examplePrivateIp2, examplePublicIp2},
+			},
+		},
 	}
 
 	for _, tc := range cases {
 		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
 			require := require.New(t)
-			actual, err := instanceToHost(tc.instance)
+			actual, err := instanceToHost(tc.instance, tc.attributes)
 			if tc.expectedErr != "" {
 				require.EqualError(err, tc.expectedErr)
 				return
@@ -128,3 +232,229 @@ func TestInstanceToHost(t *testing.T) {
 		})
 	}
 }
+
+func TestSelectByGuestAttribute(t *testing.T) {
+	require := require.New(t)
+
+	var requests atomic.Int32
+	gclient := newTestClient(t, testRoutes{
+		"/compute/v1/projects/test-project/zones/us-central1-a/instances/*/getGuestAttributes": func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			switch {
+			case r.URL.Query().Get("variableKey") != "boundary/ready":
+				w.WriteHeader(http.StatusInternalServerError)
+			case strings.HasSuffix(r.URL.Path, "/instances/ready/getGuestAttributes"):
+				w.Write([]byte(`{"variableKey": "boundary/ready", "variableValue": "true"}`))
+			case strings.HasSuffix(r.URL.Path, "/instances/booting/getGuestAttributes"):
+				w.Write([]byte(`{"variableKey": "boundary/ready", "variableValue": "false"}`))
+			default:
+				writeNotFound(w, r)
+			}
+		},
+	})
+
+	instance := func(name string) *computepb.Instance {
+		return &computepb.Instance{
+			Name:     proto.String(name),
+			SelfLink: proto.String("https://www.googleapis.com/compute/v1/projects/test-project/zones/us-central1-a/instances/" + name),
+		}
+	}
+	ready := instance("ready")
+	instances := []*computepb.Instance{instance("booting"), ready, instance("missing")}
+
+	actual, err := gclient.selectByGuestAttribute(instances, "boundary/ready", "true")
+	require.NoError(err)
+	require.Equal([]*computepb.Instance{ready}, actual)
+	require.EqualValues(3, requests.Load())
+
+	// Lookups are cached for the lifetime of the client.
+	actual, err = gclient.selectByGuestAttribute(instances, "boundary/ready", "true")
+	require.NoError(err)
+	require.Equal([]*computepb.Instance{ready}, actual)
+	require.EqualValues(3, requests.Load())
+}
+
+func TestMissingInstances(t *testing.T) {
+	require := require.New(t)
+
+	gclient := newTestClient(t, testRoutes{
+		"/compute/v1/projects/test-project/zones/us-central1-a/instances/web-1":  serveJSON(`{"name": "found"}`),
+		"/compute/v1/projects/other-project/zones/europe-west1-b/instances/db-1": serveJSON(`{"name": "found"}`),
+	})
+	catalog := testCatalog()
+
+	missing, err := gclient.missingInstances([]string{
+		"web-1",
+		"web-2",
+		"https://www.googleapis.com/compute/v1/projects/other-project/zones/europe-west1-b/instances/db-1",
+	}, catalog)
+	require.NoError(err)
+	require.Equal([]string{"web-2"}, missing)
+}
+
+func TestGetInstancesForNetworkEndpointGroup(t *testing.T) {
+	require := require.New(t)
+	gclient := newTestClient(t, testRoutes{
+		"/compute/v1/projects/test-project/zones/us-central1-a/networkEndpointGroups/web-neg/listNetworkEndpoints": serveJSON(`{"items": [
+			{"networkEndpoint": {"instance": "https://www.googleapis.com/compute/v1/projects/test-project/zones/us-central1-a/instances/web-1", "ipAddress": "10.0.0.10", "port": 8080}},
+			{"networkEndpoint": {"instance": "web-2", "ipAddress": "10.0.0.11", "port": 8080}},
+			{"networkEndpoint": {"instance": "web-1", "ipAddress": "10.0.0.12", "port": 9090}},
+			{"networkEndpoint": {"ipAddress": "10.0.0.13", "port": 8080}}
+		]}`),
+		"/compute/v1/projects/test-project/zones/us-central1-a/instances/web-1": serveInstance,
+		"/compute/v1/projects/test-project/zones/us-central1-a/instances/web-2": serveInstance,
+	})
+	catalog := testCatalog()
+
+	instances, endpoints, err := gclient.getInstancesForNetworkEndpointGroup(buildListNetworkEndpointsRequest(&SetAttributes{NetworkEndpointGroup: "web-neg"}, catalog))
+	require.NoError(err)
+	require.Len(instances, 2)
+	require.Equal("web-1", instances[0].GetName())
+	require.Equal("web-2", instances[1].GetName())
+	require.Len(endpoints[instances[0].GetSelfLink()], 2)
+	require.Len(endpoints[instances[1].GetSelfLink()], 1)
+}
+
+func TestApplyNetworkEndpoints(t *testing.T) {
+	require := require.New(t)
+
+	instance := &computepb.Instance{
+		NetworkInterfaces: []*computepb.NetworkInterface{
+			{
+				Network:   proto.String(testComputeURL + "/global/networks/default"),
+				NetworkIP: proto.String("10.0.0.2"),
+				AccessConfigs: []*computepb.AccessConfig{
+					{NatIP: proto.String("34.1.2.3")},
+				},
+				AliasIpRanges: []*computepb.AliasIpRange{
+					{IpCidrRange: proto.String("10.0.0.8/29")},
+				},
+			},
+			{
+				Network:   proto.String(testComputeURL + "/global/networks/data"),
+				NetworkIP: proto.String("10.1.0.2"),
+			},
+		},
+	}
+	endpoints := []*computepb.NetworkEndpoint{
+		{
+			IpAddress: proto.String("10.0.0.10"),
+			Port:      proto.Int32(8080),
+		},
+		{
+			IpAddress: proto.String("10.0.0.12"),
+			Port:      proto.Int32(9090),
+		},
+		{
+			IpAddress: proto.String("10.1.0.2"),
+		},
+	}
+
+	host := &pb.ListHostsResponseHost{
+		ExternalId:  testComputeURL + "/zones/us-central1-a/instances/web-1",
+		IpAddresses: []string{"10.0.0.2", "10.0.0.10"},
+	}
+	applyNetworkEndpoints(host, instance, endpoints, &SetAttributes{})
+	require.Equal([]string{"10.0.0.10", "10.0.0.12", "10.1.0.2", "10.0.0.2"}, host.IpAddresses)
+	require.Equal(float64(8080), host.Attributes.GetFields()[ConstHostAttributePort].GetNumberValue())
+
+	cases := []struct {
+		name       string
+		attributes *SetAttributes
+		expected   []string
+	}{
+		{
+			name:       "external addresses",
+			attributes: &SetAttributes{AddressKinds: []string{ConstAddressKindExternal}},
+			expected:   []string{"34.1.2.3"},
+		},
+		{
+			name:       "internal addresses",
+			attributes: &SetAttributes{AddressKinds: []string{ConstAddressKindInternal}},
+			expected:   []string{"10.1.0.2", "10.0.0.2"},
+		},
+		{
+			name:       "ipv6 addresses",
+			attributes: &SetAttributes{AddressFamilies: []string{ConstAddressFamilyIPv6}},
+		},
+		{
+			name:       "network",
+			attributes: &SetAttributes{Network: "default"},
+			expected:   []string{"10.0.0.10", "10.0.0.12", "10.0.0.2", "34.1.2.3"},
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			host, err := instanceToHost(&computepb.Instance{
+				SelfLink:          proto.String(testComputeURL + "/zones/us-central1-a/instances/web-1"),
+				NetworkInterfaces: instance.NetworkInterfaces,
+			}, tc.attributes)
+			require.NoError(err)
+			applyNetworkEndpoints(host, instance, endpoints, tc.attributes)
+			require.Equal(tc.expected, host.IpAddresses)
+		})
+	}
+
+	host = &pb.ListHostsResponseHost{}
+	applyNetworkEndpoints(host, instance, []*computepb.NetworkEndpoint{
+		{
+			IpAddress: proto.String("10.0.0.10"),
+		},
+	}, &SetAttributes{})
+	require.Equal([]string{"10.0.0.10"}, host.IpAddresses)
+	require.Nil(host.Attributes)
+}
+
+func TestRequiredClients(t *testing.T) {
+	cases := []struct {
+		name       string
+		attributes []*SetAttributes
+		expected   requiredClients
+	}{
+		{
+			name:       "instances",
+			attributes: []*SetAttributes{{Filter: "status = RUNNING"}},
+			expected:   requiredClients{instances: true},
+		},
+		{
+			name: "instance group and firewall rule",
+			attributes: []*SetAttributes{
+				{InstanceGroup: "web"},
+				{FirewallRule: "allow-ssh"},
+			},
+			expected: requiredClients{instances: true, instanceGroups: true, firewalls: true},
+		},
+		{
+			name:       "backend service",
+			attributes: []*SetAttributes{{BackendService: "web"}},
+			expected: requiredClients{
+				instances:             true,
+				instanceGroups:        true,
+				regionInstanceGroups:  true,
+				networkEndpointGroups: true,
+				backendServices:       true,
+			},
+		},
+		{
+			name: "set types",
+			attributes: []*SetAttributes{
+				{Type: ConstSetTypeCloudSQL},
+				{Type: ConstSetTypeTPU},
+				{ServiceDirectory: "prod/api"},
+			},
+			expected: requiredClients{sqlAdmin: true, tpu: true, serviceDirectory: true},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			var required requiredClients
+			for _, attributes := range tc.attributes {
+				required.add(attributes)
+			}
+			require.Equal(t, tc.expected, required)
+		})
+	}
+}
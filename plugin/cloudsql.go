@@ -0,0 +1,85 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package plugin
+
+import (
+	"errors"
+	"strings"
+
+	pb "github.com/hashicorp/boundary/sdk/pbs/plugin"
+	sqladmin "google.golang.org/api/sqladmin/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// cloudSQLAddressPrivate and cloudSQLAddressPublic are the types of
+	// the private and public addresses of a Cloud SQL instance.
+	cloudSQLAddressPrivate = "PRIVATE"
+	cloudSQLAddressPublic  = "PRIMARY"
+)
+
+// getCloudSQLHosts returns the Cloud SQL instances of the catalog
+// project matching the set as hosts.
+func (c *GoogleClient) getCloudSQLHosts(attributes *SetAttributes, catalog *CatalogAttributes) ([]*pb.ListHostsResponseHost, error) {
+	var hosts []*pb.ListHostsResponseHost
+	err := c.SQLAdminService.Instances.List(catalog.Project).Pages(c.Context, func(resp *sqladmin.InstancesListResponse) error {
+		for _, instance := range resp.Items {
+			if !cloudSQLInstanceMatches(instance, attributes) {
+				continue
+			}
+			host, err := cloudSQLInstanceToHost(instance, attributes)
+			if err != nil {
+				return err
+			}
+			hosts = append(hosts, host)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "error listing Cloud SQL instances: %s", err)
+	}
+	return hosts, nil
+}
+
+// cloudSQLInstanceMatches reports whether the Cloud SQL instance matches
+// the name prefix and labels of the set.
+func cloudSQLInstanceMatches(instance *sqladmin.DatabaseInstance, attributes *SetAttributes) bool {
+	if !strings.HasPrefix(instance.Name, attributes.NamePrefix) {
+		return false
+	}
+	var labels map[string]string
+	if instance.Settings != nil {
+		labels = instance.Settings.UserLabels
+	}
+	return labelsMatch(labels, attributes.Labels, attributes.ExcludeLabels)
+}
+
+// cloudSQLInstanceToHost converts a Cloud SQL instance to a host. The
+// private addresses are reported before the public ones, outgoing
+// addresses are never reported.
+func cloudSQLInstanceToHost(instance *sqladmin.DatabaseInstance, attributes *SetAttributes) (*pb.ListHostsResponseHost, error) {
+	if instance.SelfLink == "" {
+		return nil, errors.New("response integrity error: missing Cloud SQL instance self-link")
+	}
+
+	result := &pb.ListHostsResponseHost{
+		ExternalId:   instance.SelfLink,
+		ExternalName: instance.ConnectionName,
+	}
+	for _, addressType := range []string{cloudSQLAddressPrivate, cloudSQLAddressPublic} {
+		kind := ConstAddressKindInternal
+		if addressType == cloudSQLAddressPublic {
+			kind = ConstAddressKindExternal
+		}
+		for _, addr := range instance.IpAddresses {
+			addr := addr
+			if addr.Type != addressType || !attributes.includesAddress(kind, addressFamily(addr.IpAddress)) {
+				continue
+			}
+			result.IpAddresses = appendDistinct(result.IpAddresses, &addr.IpAddress)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,130 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package plugin
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testCloudSQLURL = "https://sqladmin.googleapis.com/v1/projects/test-project/instances"
+
+func newCloudSQLTestClient(t *testing.T) *GoogleClient {
+	return newTestClient(t, testRoutes{
+		"/v1/projects/test-project/instances": func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("pageToken") == "" {
+				w.Write([]byte(`{"nextPageToken": "page-2", "items": [
+					{
+						"name": "db-prod",
+						"selfLink": "` + testCloudSQLURL + `/db-prod",
+						"connectionName": "test-project:us-central1:db-prod",
+						"settings": {"userLabels": {"env": "prod"}},
+						"ipAddresses": [
+							{"type": "PRIMARY", "ipAddress": "34.1.2.3"},
+							{"type": "OUTGOING", "ipAddress": "34.1.2.4"},
+							{"type": "PRIVATE", "ipAddress": "10.0.0.5"}
+						]
+					},
+					{
+						"name": "db-dev",
+						"selfLink": "` + testCloudSQLURL + `/db-dev",
+						"connectionName": "test-project:us-central1:db-dev",
+						"settings": {"userLabels": {"env": "dev"}},
+						"ipAddresses": [
+							{"type": "PRIVATE", "ipAddress": "10.0.0.6"}
+						]
+					}
+				]}`))
+				return
+			}
+			w.Write([]byte(`{"items": [
+				{
+					"name": "reporting",
+					"selfLink": "` + testCloudSQLURL + `/reporting",
+					"connectionName": "test-project:us-central1:reporting",
+					"settings": {"userLabels": {"env": "prod"}},
+					"ipAddresses": [
+						{"type": "PRIVATE", "ipAddress": "10.0.0.7"}
+					]
+				}
+			]}`))
+		},
+	})
+}
+
+func TestGetCloudSQLHosts(t *testing.T) {
+	gclient := newCloudSQLTestClient(t)
+	catalog := testCatalog()
+
+	cases := []struct {
+		name         string
+		attributes   *SetAttributes
+		expected     []string
+		expectedAddr [][]string
+	}{
+		{
+			name:         "all",
+			attributes:   &SetAttributes{Type: ConstSetTypeCloudSQL},
+			expected:     []string{"db-prod", "db-dev", "reporting"},
+			expectedAddr: [][]string{{"10.0.0.5", "34.1.2.3"}, {"10.0.0.6"}, {"10.0.0.7"}},
+		},
+		{
+			name: "name prefix and labels",
+			attributes: &SetAttributes{
+				Type:       ConstSetTypeCloudSQL,
+				NamePrefix: "db-",
+				Labels:     map[string]string{"env": "prod"},
+			},
+			expected:     []string{"db-prod"},
+			expectedAddr: [][]string{{"10.0.0.5", "34.1.2.3"}},
+		},
+		{
+			name: "exclude labels",
+			attributes: &SetAttributes{
+				Type:          ConstSetTypeCloudSQL,
+				ExcludeLabels: map[string]string{"env": "prod"},
+			},
+			expected:     []string{"db-dev"},
+			expectedAddr: [][]string{{"10.0.0.6"}},
+		},
+		{
+			name: "public addresses only",
+			attributes: &SetAttributes{
+				Type:         ConstSetTypeCloudSQL,
+				NamePrefix:   "db-prod",
+				AddressKinds: []string{ConstAddressKindExternal},
+			},
+			expected:     []string{"db-prod"},
+			expectedAddr: [][]string{{"34.1.2.3"}},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			hosts, err := gclient.getCloudSQLHosts(tc.attributes, catalog)
+			require.NoError(err)
+
+			var ids, names []string
+			var addrs [][]string
+			for _, host := range hosts {
+				ids = append(ids, host.ExternalId)
+				names = append(names, host.ExternalName)
+				addrs = append(addrs, host.IpAddresses)
+			}
+			var expectedIds, expectedNames []string
+			for _, name := range tc.expected {
+				expectedIds = append(expectedIds, testCloudSQLURL+"/"+name)
+				expectedNames = append(expectedNames, "test-project:us-central1:"+name)
+			}
+			require.Equal(expectedIds, ids)
+			require.Equal(expectedNames, names)
+			require.Equal(tc.expectedAddr, addrs)
+		})
+	}
+}
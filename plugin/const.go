@@ -4,11 +4,225 @@
 package plugin
 
 const (
-	ConstListInstancesFilter = "filter"
-	ConstInstanceGroup       = "instance_group"
+	ConstListInstancesFilter       = "filter"
+	ConstListInstancesFilters      = "filters"
+	ConstInstanceGroup             = "instance_group"
+	ConstAddressKinds              = "address_kinds"
+	ConstAddressFamilies           = "address_families"
+	ConstNetwork                   = "network"
+	ConstSubnetwork                = "subnetwork"
+	ConstLabels                    = "labels"
+	ConstExcludeLabels             = "exclude_labels"
+	ConstNetworkTags               = "network_tags"
+	ConstNetworkTagsMatch          = "network_tags_match"
+	ConstServiceAccounts           = "service_accounts"
+	ConstBexprFilter               = "bexpr_filter"
+	ConstMetadata                  = "metadata"
+	ConstMetadataKeys              = "metadata_keys"
+	ConstRequireGuestAttribute     = "require_guest_attribute"
+	ConstMinUptime                 = "min_uptime"
+	ConstMinAge                    = "min_age"
+	ConstMaxAge                    = "max_age"
+	ConstInstanceTemplate          = "instance_template"
+	ConstInstances                 = "instances"
+	ConstProvisioningModels        = "provisioning_models"
+	ConstExcludePreemptible        = "exclude_preemptible"
+	ConstMaintenanceHorizon        = "maintenance_horizon"
+	ConstStatuses                  = "statuses"
+	ConstOnMissingAddress          = "on_missing_address"
+	ConstNetworkEndpointGroup      = "network_endpoint_group"
+	ConstBackendService            = "backend_service"
+	ConstBackendServiceRegion      = "backend_service_region"
+	ConstHealthyOnly               = "healthy_only"
+	ConstGKECluster                = "gke_cluster"
+	ConstGKENodePool               = "gke_node_pool"
+	ConstSetType                   = "type"
+	ConstNamePrefix                = "name_prefix"
+	ConstDataprocCluster           = "dataproc_cluster"
+	ConstRoles                     = "roles"
+	ConstServiceDirectory          = "service_directory"
+	ConstAnnotations               = "annotations"
+	ConstFirewallRule              = "firewall_rule"
+	ConstFirewallRuleInterfaceOnly = "firewall_rule_interface_only"
+	ConstTags                      = "tags"
+)
+
+const (
+	ConstAddressKindInternal = "internal"
+	ConstAddressKindExternal = "external"
+	ConstAddressKindAlias    = "alias"
+
+	ConstAddressFamilyIPv4 = "ipv4"
+	ConstAddressFamilyIPv6 = "ipv6"
+
+	ConstMatchAny = "any"
+	ConstMatchAll = "all"
+
+	ConstProvisioningModelStandard = "STANDARD"
+	ConstProvisioningModelSpot     = "SPOT"
+
+	ConstStatusRunning = "RUNNING"
+	ConstStatusAll     = "ALL"
+
+	ConstMissingAddressInclude = "include"
+	ConstMissingAddressSkip    = "skip"
+	ConstMissingAddressError   = "error"
+
+	ConstHealthStateHealthy = "HEALTHY"
+
+	ConstSetTypeCompute  = "compute"
+	ConstSetTypeCloudSQL = "cloudsql"
+	ConstSetTypeTPU      = "tpu"
+
+	ConstRoleMaster          = "master"
+	ConstRoleWorker          = "worker"
+	ConstRoleSecondaryWorker = "secondary_worker"
+)
+
+const (
+	ConstDefaultStatuses = "default_statuses"
+)
+
+const (
+	ConstHostAttributePort        = "port"
+	ConstHostAttributeAnnotations = "annotations"
 )
 
 var allowedSetFields = map[string]struct{}{
-	ConstListInstancesFilter: {},
-	ConstInstanceGroup:       {},
+	ConstListInstancesFilter:       {},
+	ConstListInstancesFilters:      {},
+	ConstInstanceGroup:             {},
+	ConstAddressKinds:              {},
+	ConstAddressFamilies:           {},
+	ConstNetwork:                   {},
+	ConstSubnetwork:                {},
+	ConstLabels:                    {},
+	ConstExcludeLabels:             {},
+	ConstNetworkTags:               {},
+	ConstNetworkTagsMatch:          {},
+	ConstServiceAccounts:           {},
+	ConstBexprFilter:               {},
+	ConstMetadata:                  {},
+	ConstMetadataKeys:              {},
+	ConstRequireGuestAttribute:     {},
+	ConstMinUptime:                 {},
+	ConstMinAge:                    {},
+	ConstMaxAge:                    {},
+	ConstInstanceTemplate:          {},
+	ConstInstances:                 {},
+	ConstProvisioningModels:        {},
+	ConstExcludePreemptible:        {},
+	ConstMaintenanceHorizon:        {},
+	ConstStatuses:                  {},
+	ConstOnMissingAddress:          {},
+	ConstNetworkEndpointGroup:      {},
+	ConstBackendService:            {},
+	ConstBackendServiceRegion:      {},
+	ConstHealthyOnly:               {},
+	ConstGKECluster:                {},
+	ConstGKENodePool:               {},
+	ConstSetType:                   {},
+	ConstNamePrefix:                {},
+	ConstDataprocCluster:           {},
+	ConstRoles:                     {},
+	ConstServiceDirectory:          {},
+	ConstAnnotations:               {},
+	ConstFirewallRule:              {},
+	ConstFirewallRuleInterfaceOnly: {},
+	ConstTags:                      {},
+}
+
+// sliceSetFields are set attributes decoded into slices. A scalar value
+// is accepted for these and treated as a single element list.
+var sliceSetFields = []string{
+	ConstListInstancesFilters,
+	ConstAddressKinds,
+	ConstAddressFamilies,
+	ConstNetworkTags,
+	ConstServiceAccounts,
+	ConstMetadataKeys,
+	ConstInstances,
+	ConstProvisioningModels,
+	ConstStatuses,
+	ConstRoles,
+	ConstTags,
+}
+
+// sourceSetFields are set attributes selecting where the instances of a
+// set are listed from, instead of listing the instances of the catalog
+// zone. A set may use at most one of them.
+var sourceSetFields = []string{
+	ConstInstanceGroup,
+	ConstInstances,
+	ConstNetworkEndpointGroup,
+	ConstBackendService,
+	ConstGKECluster,
+	ConstDataprocCluster,
+	ConstServiceDirectory,
+}
+
+// cloudSQLSetFields are the set attributes supported for sets of type
+// cloudsql.
+var cloudSQLSetFields = map[string]struct{}{
+	ConstSetType:          {},
+	ConstLabels:           {},
+	ConstExcludeLabels:    {},
+	ConstNamePrefix:       {},
+	ConstAddressKinds:     {},
+	ConstAddressFamilies:  {},
+	ConstOnMissingAddress: {},
+}
+
+// tpuSetFields are the set attributes supported for sets of type tpu.
+var tpuSetFields = map[string]struct{}{
+	ConstSetType:          {},
+	ConstLabels:           {},
+	ConstExcludeLabels:    {},
+	ConstAddressKinds:     {},
+	ConstAddressFamilies:  {},
+	ConstOnMissingAddress: {},
+}
+
+// serviceDirectorySetFields are the set attributes supported for sets
+// selecting Service Directory endpoints.
+var serviceDirectorySetFields = map[string]struct{}{
+	ConstServiceDirectory: {},
+	ConstAnnotations:      {},
+	ConstAddressFamilies:  {},
+	ConstOnMissingAddress: {},
+}
+
+// typeSetFields are the set attributes supported by each set type other
+// than compute, which supports every set attribute.
+var typeSetFields = map[string]map[string]struct{}{
+	ConstSetTypeCloudSQL: cloudSQLSetFields,
+	ConstSetTypeTPU:      tpuSetFields,
+}
+
+var allowedAddressKinds = map[string]struct{}{
+	ConstAddressKindInternal: {},
+	ConstAddressKindExternal: {},
+	ConstAddressKindAlias:    {},
+}
+
+var allowedAddressFamilies = map[string]struct{}{
+	ConstAddressFamilyIPv4: {},
+	ConstAddressFamilyIPv6: {},
+}
+
+var allowedProvisioningModels = map[string]struct{}{
+	ConstProvisioningModelStandard: {},
+	ConstProvisioningModelSpot:     {},
+}
+
+var allowedMissingAddressPolicies = map[string]struct{}{
+	ConstMissingAddressInclude: {},
+	ConstMissingAddressSkip:    {},
+	ConstMissingAddressError:   {},
+}
+
+var allowedRoles = map[string]struct{}{
+	ConstRoleMaster:          {},
+	ConstRoleWorker:          {},
+	ConstRoleSecondaryWorker: {},
 }
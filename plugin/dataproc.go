@@ -0,0 +1,99 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package plugin
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	computepb "cloud.google.com/go/compute/apiv1/computepb"
+	dataproc "google.golang.org/api/dataproc/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// dataprocClusterReference returns the project, region and name of the
+// Dataproc cluster of the set, given by name or resource name, e.g.
+// projects/my-project/regions/us-central1/clusters/etl. Bare names refer
+// to a cluster in the catalog project and the region of the catalog zone.
+func dataprocClusterReference(attributes *SetAttributes, catalog *CatalogAttributes) (string, string, string) {
+	project, region, name := catalog.Project, zoneRegion(catalog.Zone), attributes.DataprocCluster
+	if strings.Contains(attributes.DataprocCluster, "/") {
+		segments := resourceSegments(attributes.DataprocCluster)
+		if segments["projects"] != "" {
+			project = segments["projects"]
+		}
+		if segments["regions"] != "" {
+			region = segments["regions"]
+		}
+		name = segments["clusters"]
+	}
+	return project, region, name
+}
+
+// zoneRegion returns the region of a zone, e.g. us-central1 for
+// us-central1-a.
+func zoneRegion(zone string) string {
+	if i := strings.LastIndex(zone, "-"); i > 0 {
+		return zone[:i]
+	}
+	return zone
+}
+
+// dataprocInstanceNames returns the names of the instances of the
+// cluster in the roles, or in every role if none are given.
+func dataprocInstanceNames(cluster *dataproc.Cluster, roles []string) []string {
+	if cluster.Config == nil {
+		return nil
+	}
+	groups := map[string]*dataproc.InstanceGroupConfig{
+		ConstRoleMaster:          cluster.Config.MasterConfig,
+		ConstRoleWorker:          cluster.Config.WorkerConfig,
+		ConstRoleSecondaryWorker: cluster.Config.SecondaryWorkerConfig,
+	}
+	if len(roles) == 0 {
+		roles = []string{ConstRoleMaster, ConstRoleWorker, ConstRoleSecondaryWorker}
+	}
+
+	var names []string
+	for _, role := range roles {
+		if group := groups[role]; group != nil {
+			names = append(names, group.InstanceNames...)
+		}
+	}
+	return names
+}
+
+// getInstancesForDataprocCluster returns the Compute Engine instances of
+// the Dataproc cluster of the set in the selected roles. The instance
+// names are resolved through the Dataproc API on every call, so
+// ephemeral clusters need no labels maintained for Boundary.
+func (c *GoogleClient) getInstancesForDataprocCluster(attributes *SetAttributes, catalog *CatalogAttributes) ([]*computepb.Instance, error) {
+	project, region, name := dataprocClusterReference(attributes, catalog)
+	cluster, err := c.DataprocService.Projects.Regions.Clusters.Get(project, region, name).Context(c.Context).Do()
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "error getting Dataproc cluster %s: %s", name, err)
+	}
+
+	zone := catalog.Zone
+	if cluster.Config != nil && cluster.Config.GceClusterConfig != nil && cluster.Config.GceClusterConfig.ZoneUri != "" {
+		zone = path.Base(cluster.Config.GceClusterConfig.ZoneUri)
+	}
+
+	var refs []string
+	for _, instance := range dataprocInstanceNames(cluster, attributes.Roles) {
+		refs = append(refs, fmt.Sprintf("projects/%s/zones/%s/instances/%s", project, zone, instance))
+	}
+
+	var hosts []*computepb.Instance
+	for _, request := range buildInstancesByNameRequests(&SetAttributes{Instances: refs}, catalog) {
+		instances, err := c.getInstances(request)
+		if err != nil {
+			return nil, err
+		}
+		hosts = append(hosts, instances...)
+	}
+	return hosts, nil
+}
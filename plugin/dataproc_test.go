@@ -0,0 +1,98 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package plugin
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testNameFilterRegexp = regexp.MustCompile(`name = "([^"]+)"`)
+
+func newDataprocTestClient(t *testing.T) *GoogleClient {
+	return newTestClient(t, testRoutes{
+		"/v1/projects/test-project/regions/us-central1/clusters/etl": serveJSON(`{"clusterName": "etl", "config": {
+			"gceClusterConfig": {"zoneUri": "` + testComputeURL + `/zones/us-central1-f"},
+			"masterConfig": {"instanceNames": ["etl-m"]},
+			"workerConfig": {"instanceNames": ["etl-w-0", "etl-w-1"]},
+			"secondaryWorkerConfig": {"instanceNames": ["etl-sw-abcd"]}
+		}}`),
+		"/compute/v1/projects/test-project/zones/us-central1-f/instances": func(w http.ResponseWriter, r *http.Request) {
+			var items []string
+			for _, match := range testNameFilterRegexp.FindAllStringSubmatch(r.URL.Query().Get("filter"), -1) {
+				items = append(items, fmt.Sprintf(`{"name": %q, "selfLink": %q}`, match[1], testComputeURL+"/zones/us-central1-f/instances/"+match[1]))
+			}
+			w.Write([]byte(`{"items": [` + strings.Join(items, ",") + `]}`))
+		},
+	})
+}
+
+func TestGetInstancesForDataprocCluster(t *testing.T) {
+	gclient := newDataprocTestClient(t)
+	catalog := testCatalog()
+
+	cases := []struct {
+		name        string
+		attributes  *SetAttributes
+		expected    []string
+		expectedErr string
+	}{
+		{
+			name:       "all roles",
+			attributes: &SetAttributes{DataprocCluster: "etl"},
+			expected:   []string{"etl-m", "etl-w-0", "etl-w-1", "etl-sw-abcd"},
+		},
+		{
+			name: "master",
+			attributes: &SetAttributes{
+				DataprocCluster: "projects/test-project/regions/us-central1/clusters/etl",
+				Roles:           []string{ConstRoleMaster},
+			},
+			expected: []string{"etl-m"},
+		},
+		{
+			name: "workers",
+			attributes: &SetAttributes{
+				DataprocCluster: "etl",
+				Roles:           []string{ConstRoleWorker, ConstRoleSecondaryWorker},
+			},
+			expected: []string{"etl-w-0", "etl-w-1", "etl-sw-abcd"},
+		},
+		{
+			name:        "missing cluster",
+			attributes:  &SetAttributes{DataprocCluster: "other"},
+			expectedErr: "error getting Dataproc cluster other",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			instances, err := gclient.getInstancesForDataprocCluster(tc.attributes, catalog)
+			if tc.expectedErr != "" {
+				require.ErrorContains(err, tc.expectedErr)
+				return
+			}
+			require.NoError(err)
+
+			var names []string
+			for _, instance := range instances {
+				names = append(names, instance.GetName())
+			}
+			require.Equal(tc.expected, names)
+		})
+	}
+}
+
+func TestZoneRegion(t *testing.T) {
+	require.Equal(t, "us-central1", zoneRegion("us-central1-a"))
+	require.Equal(t, "europe-west4", zoneRegion("europe-west4-b"))
+}
@@ -0,0 +1,95 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package plugin
+
+import (
+	"strings"
+
+	computepb "cloud.google.com/go/compute/apiv1/computepb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// firewallRuleReference returns the project and name of the firewall
+// rule of the set, given by name, relative path such as
+// projects/my-project/global/firewalls/allow-ssh or full URL. Bare names
+// refer to a rule in the catalog project.
+func firewallRuleReference(attributes *SetAttributes, catalog *CatalogAttributes) (string, string) {
+	if !strings.Contains(attributes.FirewallRule, "/") {
+		return catalog.Project, attributes.FirewallRule
+	}
+	segments := resourceSegments(attributes.FirewallRule)
+	project := segments["projects"]
+	if project == "" {
+		project = catalog.Project
+	}
+	return project, segments["firewalls"]
+}
+
+// getFirewallRule returns the firewall rule of the set.
+func (c *GoogleClient) getFirewallRule(attributes *SetAttributes, catalog *CatalogAttributes) (*computepb.Firewall, error) {
+	project, name := firewallRuleReference(attributes, catalog)
+	rule, err := c.FirewallClient.Get(c.Context, &computepb.GetFirewallRequest{
+		Firewall: name,
+		Project:  project,
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "error getting firewall rule %s: %s", name, err)
+	}
+	return rule, nil
+}
+
+// selectByFirewallRule returns the instances the firewall rule applies
+// to.
+func selectByFirewallRule(instances []*computepb.Instance, rule *computepb.Firewall) []*computepb.Instance {
+	selected := instances[:0]
+	for _, instance := range instances {
+		if firewallRuleApplies(instance, rule) {
+			selected = append(selected, instance)
+		}
+	}
+	return selected
+}
+
+// firewallRuleApplies reports whether the firewall rule makes the
+// instance reachable: the rule is an enabled ingress rule allowing
+// traffic, and the instance has an interface in the network of the rule
+// and one of its target tags or target service accounts. Rules without
+// targets apply to every instance in the network.
+func firewallRuleApplies(instance *computepb.Instance, rule *computepb.Firewall) bool {
+	if !firewallRuleAllowsIngress(rule) {
+		return false
+	}
+
+	inNetwork := false
+	for _, iface := range instance.GetNetworkInterfaces() {
+		if resourceMatches(iface.GetNetwork(), rule.GetNetwork()) {
+			inNetwork = true
+			break
+		}
+	}
+	if !inNetwork {
+		return false
+	}
+
+	switch {
+	case len(rule.GetTargetTags()) > 0:
+		return networkTagsMatch(instance, rule.GetTargetTags(), ConstMatchAny)
+	case len(rule.GetTargetServiceAccounts()) > 0:
+		return serviceAccountsMatch(instance, rule.GetTargetServiceAccounts())
+	default:
+		return true
+	}
+}
+
+// firewallRuleAllowsIngress reports whether the firewall rule is an
+// enabled ingress rule allowing traffic. Egress rules and deny rules
+// never make an instance reachable.
+func firewallRuleAllowsIngress(rule *computepb.Firewall) bool {
+	if rule.GetDisabled() || len(rule.GetAllowed()) == 0 {
+		return false
+	}
+	// The direction defaults to ingress.
+	return rule.Direction == nil || rule.GetDirection() == computepb.Firewall_INGRESS.String()
+}
@@ -0,0 +1,138 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package plugin
+
+import (
+	"testing"
+
+	computepb "cloud.google.com/go/compute/apiv1/computepb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestGetFirewallRule(t *testing.T) {
+	gclient := newTestClient(t, testRoutes{
+		"/compute/v1/projects/test-project/global/firewalls/allow-ssh": serveJSON(`{"name": "allow-ssh", "network": "` + testComputeURL + `/global/networks/default", "targetTags": ["ssh"]}`),
+		"/compute/v1/projects/host-project/global/firewalls/allow-db":  serveJSON(`{"name": "allow-db", "network": "https://www.googleapis.com/compute/v1/projects/host-project/global/networks/shared"}`),
+	})
+	catalog := testCatalog()
+
+	rule, err := gclient.getFirewallRule(&SetAttributes{FirewallRule: "allow-ssh"}, catalog)
+	require.NoError(t, err)
+	require.Equal(t, []string{"ssh"}, rule.GetTargetTags())
+
+	rule, err = gclient.getFirewallRule(&SetAttributes{FirewallRule: "projects/host-project/global/firewalls/allow-db"}, catalog)
+	require.NoError(t, err)
+	require.Equal(t, "allow-db", rule.GetName())
+
+	_, err = gclient.getFirewallRule(&SetAttributes{FirewallRule: "missing"}, catalog)
+	require.ErrorContains(t, err, "error getting firewall rule missing")
+}
+
+func TestSelectByFirewallRule(t *testing.T) {
+	network := testComputeURL + "/global/networks/default"
+	newInstance := func(name, network string, tags []string, serviceAccount string) *computepb.Instance {
+		return &computepb.Instance{
+			Name: proto.String(name),
+			NetworkInterfaces: []*computepb.NetworkInterface{
+				{Network: proto.String(network)},
+			},
+			Tags: &computepb.Tags{Items: tags},
+			ServiceAccounts: []*computepb.ServiceAccount{
+				{Email: proto.String(serviceAccount)},
+			},
+		}
+	}
+	instances := []*computepb.Instance{
+		newInstance("web", network, []string{"http", "ssh"}, "web@test-project.iam.gserviceaccount.com"),
+		newInstance("db", network, []string{"db"}, "db@test-project.iam.gserviceaccount.com"),
+		newInstance("other", testComputeURL+"/global/networks/other", []string{"ssh"}, "web@test-project.iam.gserviceaccount.com"),
+	}
+
+	allowSSH := []*computepb.Allowed{
+		{IPProtocol: proto.String("tcp"), Ports: []string{"22"}},
+	}
+
+	cases := []struct {
+		name     string
+		rule     *computepb.Firewall
+		expected []string
+	}{
+		{
+			name: "target tags",
+			rule: &computepb.Firewall{
+				Network:    proto.String(network),
+				Allowed:    allowSSH,
+				TargetTags: []string{"ssh"},
+			},
+			expected: []string{"web"},
+		},
+		{
+			name: "target service accounts",
+			rule: &computepb.Firewall{
+				Network:               proto.String(network),
+				Allowed:               allowSSH,
+				TargetServiceAccounts: []string{"db@test-project.iam.gserviceaccount.com"},
+			},
+			expected: []string{"db"},
+		},
+		{
+			name: "all instances in network",
+			rule: &computepb.Firewall{
+				Network: proto.String(network),
+				Allowed: allowSSH,
+			},
+			expected: []string{"web", "db"},
+		},
+		{
+			name: "disabled",
+			rule: &computepb.Firewall{
+				Network:  proto.String(network),
+				Allowed:  allowSSH,
+				Disabled: proto.Bool(true),
+			},
+		},
+		{
+			name: "explicit ingress",
+			rule: &computepb.Firewall{
+				Network:    proto.String(network),
+				Allowed:    allowSSH,
+				Direction:  proto.String(computepb.Firewall_INGRESS.String()),
+				TargetTags: []string{"db"},
+			},
+			expected: []string{"db"},
+		},
+		{
+			name: "deny",
+			rule: &computepb.Firewall{
+				Network:    proto.String(network),
+				Denied:     []*computepb.Denied{{IPProtocol: proto.String("tcp")}},
+				TargetTags: []string{"ssh"},
+			},
+		},
+		{
+			name: "egress",
+			rule: &computepb.Firewall{
+				Network:    proto.String(network),
+				Allowed:    allowSSH,
+				Direction:  proto.String(computepb.Firewall_EGRESS.String()),
+				TargetTags: []string{"ssh"},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			input := make([]*computepb.Instance, len(instances))
+			copy(input, instances)
+
+			var names []string
+			for _, instance := range selectByFirewallRule(input, tc.rule) {
+				names = append(names, instance.GetName())
+			}
+			require.Equal(t, tc.expected, names)
+		})
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package plugin
+
+import (
+	"fmt"
+	"strings"
+
+	computepb "cloud.google.com/go/compute/apiv1/computepb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// gkeClusterName returns the resource name of the GKE cluster of the set,
+// given by name or resource name, e.g.
+// projects/my-project/locations/us-central1/clusters/my-cluster. Bare
+// names refer to a cluster in the catalog project and zone.
+func gkeClusterName(attributes *SetAttributes, catalog *CatalogAttributes) string {
+	project, location, name := catalog.Project, catalog.Zone, attributes.GKECluster
+	if strings.Contains(attributes.GKECluster, "/") {
+		segments := resourceSegments(attributes.GKECluster)
+		if segments["projects"] != "" {
+			project = segments["projects"]
+		}
+		if segments["locations"] != "" {
+			location = segments["locations"]
+		}
+		name = segments["clusters"]
+	}
+	return fmt.Sprintf("projects/%s/locations/%s/clusters/%s", project, location, name)
+}
+
+// getInstancesForGKECluster returns the nodes of the GKE cluster of the
+// set, or of one of its node pools. The managed instance groups of the
+// node pools are resolved on every call since GKE recreates them on
+// upgrades.
+func (c *GoogleClient) getInstancesForGKECluster(attributes *SetAttributes, catalog *CatalogAttributes) ([]*computepb.Instance, error) {
+	name := gkeClusterName(attributes, catalog)
+	cluster, err := c.ContainerService.Projects.Locations.Clusters.Get(name).Context(c.Context).Do()
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "error getting GKE cluster %s: %s", name, err)
+	}
+
+	var groupURLs []string
+	foundPool := false
+	for _, pool := range cluster.NodePools {
+		if attributes.GKENodePool != "" && pool.Name != attributes.GKENodePool {
+			continue
+		}
+		foundPool = true
+		groupURLs = append(groupURLs, pool.InstanceGroupUrls...)
+	}
+	if attributes.GKENodePool != "" && !foundPool {
+		return nil, status.Errorf(codes.InvalidArgument, "node pool %s not found in GKE cluster %s", attributes.GKENodePool, name)
+	}
+
+	instanceState := instanceGroupState(attributes, catalog)
+	var hosts []*computepb.Instance
+	for _, url := range groupURLs {
+		members, err := c.getInstancesForInstanceGroupURL(url, instanceState)
+		if err != nil {
+			return nil, err
+		}
+		hosts = append(hosts, members...)
+	}
+	return hosts, nil
+}
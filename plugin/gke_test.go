@@ -0,0 +1,90 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package plugin
+
+import (
+	"net/http"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetInstancesForGKECluster(t *testing.T) {
+	gclient := newTestClient(t, testRoutes{
+		"/v1/projects/test-project/locations/us-central1/clusters/prod": serveJSON(`{"name": "prod", "nodePools": [
+			{"name": "default-pool", "instanceGroupUrls": [
+				"` + testComputeURL + `/zones/us-central1-a/instanceGroupManagers/gke-prod-default-pool-a",
+				"` + testComputeURL + `/zones/us-central1-b/instanceGroupManagers/gke-prod-default-pool-b"
+			]},
+			{"name": "gpu-pool", "instanceGroupUrls": [
+				"` + testComputeURL + `/zones/us-central1-a/instanceGroupManagers/gke-prod-gpu-pool-a"
+			]}
+		]}`),
+		"/compute/v1/projects/test-project/zones/*/instanceGroups/*/listInstances": func(w http.ResponseWriter, r *http.Request) {
+			group := path.Base(path.Dir(r.URL.Path))
+			w.Write([]byte(`{"items": [{"instance": "` + testComputeURL + `/zones/us-central1-a/instances/` + group + `-node"}]}`))
+		},
+		"/compute/v1/projects/test-project/zones/*/instances/*": serveInstance,
+	})
+	catalog := testCatalog()
+
+	cases := []struct {
+		name        string
+		attributes  *SetAttributes
+		expected    []string
+		expectedErr string
+	}{
+		{
+			name: "cluster",
+			attributes: &SetAttributes{
+				GKECluster: "projects/test-project/locations/us-central1/clusters/prod",
+			},
+			expected: []string{"gke-prod-default-pool-a-node", "gke-prod-default-pool-b-node", "gke-prod-gpu-pool-a-node"},
+		},
+		{
+			name: "node pool",
+			attributes: &SetAttributes{
+				GKECluster:  "locations/us-central1/clusters/prod",
+				GKENodePool: "gpu-pool",
+			},
+			expected: []string{"gke-prod-gpu-pool-a-node"},
+		},
+		{
+			name: "unknown node pool",
+			attributes: &SetAttributes{
+				GKECluster:  "locations/us-central1/clusters/prod",
+				GKENodePool: "spot-pool",
+			},
+			expectedErr: "node pool spot-pool not found in GKE cluster projects/test-project/locations/us-central1/clusters/prod",
+		},
+		{
+			name: "unknown cluster",
+			attributes: &SetAttributes{
+				GKECluster: "staging",
+			},
+			expectedErr: "error getting GKE cluster projects/test-project/locations/us-central1-a/clusters/staging",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			instances, err := gclient.getInstancesForGKECluster(tc.attributes, catalog)
+			if tc.expectedErr != "" {
+				require.ErrorContains(err, tc.expectedErr)
+				return
+			}
+			require.NoError(err)
+
+			var names []string
+			for _, instance := range instances {
+				names = append(names, instance.GetName())
+			}
+			require.Equal(tc.expected, names)
+		})
+	}
+}
@@ -6,15 +6,23 @@ package plugin
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"strings"
 
 	compute "cloud.google.com/go/compute/apiv1"
 	computepb "cloud.google.com/go/compute/apiv1/computepb"
+	"github.com/hashicorp/boundary/sdk/pbs/controller/api/resources/hostcatalogs"
 	"github.com/hashicorp/boundary/sdk/pbs/controller/api/resources/hostsets"
 	pb "github.com/hashicorp/boundary/sdk/pbs/plugin"
+	"github.com/hashicorp/go-bexpr"
 	errors "github.com/joatmon08/boundary-plugin-google/internal/errors"
+	"github.com/joatmon08/boundary-plugin-google/internal/filter"
+	"github.com/joatmon08/boundary-plugin-google/internal/values"
 	"github.com/mitchellh/mapstructure"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
 )
 
 type GooglePlugin struct {
@@ -53,7 +61,21 @@ func (p *GooglePlugin) OnUpdateCatalog(_ context.Context, req *pb.OnUpdateCatalo
 		return nil, status.Error(codes.FailedPrecondition, "current catalog is nil")
 	}
 
-	secrets := req.GetNewCatalog().GetSecrets()
+	newCatalog := req.GetNewCatalog()
+	if newCatalog == nil {
+		return nil, status.Error(codes.InvalidArgument, "new catalog is nil")
+	}
+
+	attrs := newCatalog.GetAttributes()
+	if attrs == nil {
+		return nil, status.Error(codes.InvalidArgument, "new catalog missing attributes")
+	}
+
+	if _, err := getCatalogAttributes(attrs); err != nil {
+		return nil, err
+	}
+
+	secrets := newCatalog.GetSecrets()
 	if secrets == nil {
 		// If new secrets weren't passed in, don't rotate what we have on
 		// update.
@@ -85,17 +107,23 @@ func (p *GooglePlugin) OnDeleteCatalog(ctx context.Context, req *pb.OnDeleteCata
 	return &pb.OnDeleteCatalogResponse{}, nil
 }
 
-func (p *GooglePlugin) OnCreateSet(_ context.Context, req *pb.OnCreateSetRequest) (*pb.OnCreateSetResponse, error) {
+func (p *GooglePlugin) OnCreateSet(ctx context.Context, req *pb.OnCreateSetRequest) (*pb.OnCreateSetResponse, error) {
 	if err := validateSet(req.GetSet()); err != nil {
 		return nil, err
 	}
+	if err := validateSetInstances(ctx, req.GetCatalog(), req.GetSet()); err != nil {
+		return nil, err
+	}
 	return &pb.OnCreateSetResponse{}, nil
 }
 
-func (p *GooglePlugin) OnUpdateSet(_ context.Context, req *pb.OnUpdateSetRequest) (*pb.OnUpdateSetResponse, error) {
+func (p *GooglePlugin) OnUpdateSet(ctx context.Context, req *pb.OnUpdateSetRequest) (*pb.OnUpdateSetResponse, error) {
 	if err := validateSet(req.GetNewSet()); err != nil {
 		return nil, err
 	}
+	if err := validateSetInstances(ctx, req.GetCatalog(), req.GetNewSet()); err != nil {
+		return nil, err
+	}
 	return &pb.OnUpdateSetResponse{}, nil
 }
 
@@ -127,8 +155,12 @@ func (p *GooglePlugin) ListHosts(ctx context.Context, req *pb.ListHostsRequest)
 
 	type hostSetQuery struct {
 		Id             string
-		InputInstances *computepb.ListInstancesRequest
+		Attributes     *SetAttributes
+		InputInstances []*computepb.ListInstancesRequest
 		InputGroups    *computepb.ListInstancesInstanceGroupsRequest
+		InputEndpoints *computepb.ListNetworkEndpointsNetworkEndpointGroupsRequest
+		GroupFilters   []*filter.Filter
+		BexprFilter    *bexpr.Evaluator
 		Project        string
 		Zone           string
 		Output         []*computepb.Instance
@@ -150,96 +182,320 @@ func (p *GooglePlugin) ListHosts(ctx context.Context, req *pb.ListHostsRequest)
 			return nil, err
 		}
 
-		if setAttrs.InstanceGroup != "" {
+		var bexprFilter *bexpr.Evaluator
+		if setAttrs.BexprFilter != "" {
+			bexprFilter, err = newBexprEvaluator(setAttrs.BexprFilter)
+			if err != nil {
+				return nil, status.Errorf(codes.InvalidArgument, "error in bexpr filter for host set id %q: %s", set.GetId(), err)
+			}
+		}
+
+		switch {
+		case setAttrs.Type == ConstSetTypeCloudSQL, setAttrs.Type == ConstSetTypeTPU, setAttrs.ServiceDirectory != "":
 			queries[i] = hostSetQuery{
-				Id:          set.GetId(),
-				InputGroups: buildListInstanceGroupsRequest(setAttrs, catalogAttributes),
+				Id:         set.GetId(),
+				Attributes: setAttrs,
 			}
-		} else {
+		case len(setAttrs.Instances) > 0:
 			queries[i] = hostSetQuery{
 				Id:             set.GetId(),
-				InputInstances: buildListInstancesRequest(setAttrs, catalogAttributes),
+				Attributes:     setAttrs,
+				InputInstances: buildInstancesByNameRequests(setAttrs, catalogAttributes),
+				BexprFilter:    bexprFilter,
+			}
+		case setAttrs.GKECluster != "", setAttrs.DataprocCluster != "", setAttrs.BackendService != "",
+			setAttrs.NetworkEndpointGroup != "", setAttrs.InstanceGroup != "":
+			// Members of these sources are listed without a server side
+			// filter, the filters of the set are evaluated by the plugin.
+			groupFilters, err := buildInstanceGroupFilters(setAttrs)
+			if err != nil {
+				return nil, status.Errorf(codes.InvalidArgument, "error in filter for host set id %q: %s", set.GetId(), err)
+			}
+			queries[i] = hostSetQuery{
+				Id:           set.GetId(),
+				Attributes:   setAttrs,
+				GroupFilters: groupFilters,
+				BexprFilter:  bexprFilter,
+			}
+			switch {
+			case setAttrs.NetworkEndpointGroup != "":
+				queries[i].InputEndpoints = buildListNetworkEndpointsRequest(setAttrs, catalogAttributes)
+			case setAttrs.InstanceGroup != "":
+				queries[i].InputGroups = buildListInstanceGroupsRequest(setAttrs, catalogAttributes)
+			}
+		default:
+			queries[i] = hostSetQuery{
+				Id:             set.GetId(),
+				Attributes:     setAttrs,
+				InputInstances: buildListInstancesRequests(setAttrs, catalogAttributes),
+				BexprFilter:    bexprFilter,
 			}
 		}
 	}
 
-	instancesClient, err := compute.NewInstancesRESTClient(ctx)
-	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "error creating NewInstancesRESTClient: %s", err)
+	// Only the clients needed by the sets are created.
+	var required requiredClients
+	for _, query := range queries {
+		required.add(query.Attributes)
 	}
-
-	instanceGroupsClient, err := compute.NewInstanceGroupsRESTClient(ctx)
+	gclient, err := newGoogleClient(ctx, required)
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "error creating NewInstanceGroupsRESTClient: %s", err)
-	}
-
-	gclient := GoogleClient{
-		InstancesClient:     instancesClient,
-		InstanceGroupClient: instanceGroupsClient,
-		Context:             ctx,
+		return nil, err
 	}
+	defer gclient.Close()
 
 	// Run all queries now and assemble output.
 	var maxLen int
 	for i, query := range queries {
+		onMissingAddress := missingAddressPolicy(query.Attributes, catalogAttributes)
+
+		// Set types other than compute, and Service Directory endpoints,
+		// list their hosts directly, none of the instance selectors apply
+		// to them.
+		var hosts []*pb.ListHostsResponseHost
+		var direct bool
+		switch {
+		case query.Attributes.Type == ConstSetTypeCloudSQL:
+			hosts, err = gclient.getCloudSQLHosts(query.Attributes, catalogAttributes)
+			direct = true
+		case query.Attributes.Type == ConstSetTypeTPU:
+			hosts, err = gclient.getTPUHosts(query.Attributes, catalogAttributes)
+			direct = true
+		case query.Attributes.ServiceDirectory != "":
+			hosts, err = gclient.getServiceDirectoryHosts(query.Attributes, catalogAttributes)
+			direct = true
+		}
+		if direct {
+			if err != nil {
+				return nil, status.Errorf(codes.InvalidArgument, "error listing hosts for host set id %q: %s", query.Id, err)
+			}
+			for _, host := range hosts {
+				keep, err := keepHost(host, onMissingAddress, query.Id)
+				if err != nil {
+					return nil, err
+				}
+				if keep {
+					queries[i].OutputHosts = append(queries[i].OutputHosts, host)
+					maxLen++
+				}
+			}
+			continue
+		}
+
 		var output []*computepb.Instance
-		if query.InputGroups != nil {
+		var endpoints map[string][]*computepb.NetworkEndpoint
+		switch {
+		case query.Attributes.GKECluster != "":
+			output, err = gclient.getInstancesForGKECluster(query.Attributes, catalogAttributes)
+		case query.Attributes.DataprocCluster != "":
+			output, err = gclient.getInstancesForDataprocCluster(query.Attributes, catalogAttributes)
+		case query.Attributes.BackendService != "":
+			output, endpoints, err = gclient.getInstancesForBackendService(query.Attributes, catalogAttributes)
+		case query.InputEndpoints != nil:
+			output, endpoints, err = gclient.getInstancesForNetworkEndpointGroup(query.InputEndpoints)
+		case query.InputGroups != nil:
 			output, err = gclient.getInstancesForInstanceGroup(query.InputGroups)
+		default:
+			for _, input := range query.InputInstances {
+				var instances []*computepb.Instance
+				instances, err = gclient.getInstances(input)
+				if err != nil {
+					break
+				}
+				output = append(output, instances...)
+			}
+		}
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "error listing instances for host set id %q: %s", query.Id, err)
+		}
+		output, err = filterInstances(output, query.GroupFilters)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "error filtering instances for host set id %q: %s", query.Id, err)
+		}
+
+		// Named instances that no longer exist, or no longer match the
+		// labels of the set, are dropped from the set.
+		if len(query.Attributes.Instances) > 0 {
+			for _, ref := range missingInstanceReferences(query.Attributes, catalogAttributes, output) {
+				slog.Warn("instance in host set not found", "host_set_id", query.Id, "instance", ref)
+			}
+		}
+
+		// The firewall rule is resolved on every sync, so that the set
+		// follows changes to its targets.
+		if query.Attributes.FirewallRule != "" {
+			rule, err := gclient.getFirewallRule(query.Attributes, catalogAttributes)
 			if err != nil {
-				return nil, status.Errorf(codes.InvalidArgument, "error running getInstancesForInstanceGroup for host set id %q: %s", query.Id, err)
+				return nil, status.Errorf(codes.InvalidArgument, "error running getFirewallRule for host set id %q: %s", query.Id, err)
+			}
+			if !firewallRuleAllowsIngress(rule) {
+				slog.Warn("firewall rule of host set does not allow ingress, no instances selected", "host_set_id", query.Id, "firewall_rule", rule.GetSelfLink())
+			}
+			output = selectByFirewallRule(output, rule)
+			if query.Attributes.FirewallRuleInterfaceOnly {
+				query.Attributes.Network = resourcePath(rule.GetNetwork())
 			}
-		} else {
-			output, err = gclient.getInstances(query.InputInstances)
+		}
+
+		// Statuses are pushed into the list filters where possible, but
+		// instance groups and named instances are listed in every status.
+		output = selectByStatus(output, instanceStatuses(query.Attributes, catalogAttributes))
+
+		output = selectInstances(output, query.Attributes)
+		output, err = bexprInstances(output, query.BexprFilter)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "error evaluating bexpr filter for host set id %q: %s", query.Id, err)
+		}
+
+		// Secure tags and guest attributes need a request per instance,
+		// so they are checked last, on the instances left after all other
+		// selectors.
+		if len(query.Attributes.Tags) > 0 {
+			output, err = gclient.selectByTags(output, query.Attributes.Tags)
 			if err != nil {
-				return nil, status.Errorf(codes.InvalidArgument, "error running getInstances for host set id %q: %s", query.Id, err)
+				return nil, status.Errorf(codes.InvalidArgument, "error checking secure tags for host set id %q: %s", query.Id, err)
 			}
 		}
 
+		if query.Attributes.RequireGuestAttribute != "" {
+			key, value, err := parseGuestAttribute(query.Attributes.RequireGuestAttribute)
+			if err != nil {
+				return nil, status.Errorf(codes.InvalidArgument, "error in guest attribute for host set id %q: %s", query.Id, err)
+			}
+			output, err = gclient.selectByGuestAttribute(output, key, value)
+			if err != nil {
+				return nil, status.Errorf(codes.InvalidArgument, "error checking guest attributes for host set id %q: %s", query.Id, err)
+			}
+		}
 		queries[i].Output = output
 
 		// Process the output here, we will normalize this into a single
 		// set of hosts afterwards (possibly removing duplicates).
 		for _, instance := range output {
-			host, err := instanceToHost(instance)
+			host, err := instanceToHost(instance, query.Attributes)
 
 			if err != nil {
 				return nil, status.Errorf(codes.InvalidArgument, "error processing host results for host set id %q: %s", query.Id, err)
 			}
+			if instanceEndpoints, ok := endpoints[instance.GetSelfLink()]; ok {
+				applyNetworkEndpoints(host, instance, instanceEndpoints, query.Attributes)
+			}
+
+			keep, err := keepHost(host, onMissingAddress, query.Id)
+			if err != nil {
+				return nil, err
+			}
+			if !keep {
+				continue
+			}
 
 			queries[i].OutputHosts = append(queries[i].OutputHosts, host)
 			maxLen++
 		}
 	}
 
-	// Now de-duplicate the hosts for the output. Maintain two sets:
+	results := make([]setHosts, 0, len(queries))
+	for _, query := range queries {
+		results = append(results, setHosts{setId: query.Id, hosts: query.OutputHosts})
+	}
+
+	return &pb.ListHostsResponse{
+		Hosts: dedupeHosts(results, maxLen),
+	}, nil
+}
+
+// setHosts are the hosts found for a host set.
+type setHosts struct {
+	setId string
+	hosts []*pb.ListHostsResponseHost
+}
+
+// dedupeHosts normalizes the hosts of all sets into a single list of
+// hosts, each listing the IDs of the sets it was found in. Sets select
+// different addresses and attributes of a host, e.g. through
+// address_kinds, network or network endpoint groups, so a host found in
+// several sets gets the addresses and attributes of all of them.
+func dedupeHosts(sets []setHosts, capacity int) []*pb.ListHostsResponseHost {
+	// Maintain two sets:
 	// * A slice of hosts that will be used in the output
 	// * A map of hosts indexed by their external ID
 	//
 	// The map will is used in de-duplication to determine whether or
-	// not we've seen the host before to simply add the query's set ID
-	// to the list of set IDs that the host was seen in.
-	hostResultSlice := make([]*pb.ListHostsResponseHost, 0, maxLen)
+	// not we've seen the host before to merge it and add the set ID to
+	// the list of set IDs that the host was seen in.
+	hostResultSlice := make([]*pb.ListHostsResponseHost, 0, capacity)
 	hostResultMap := make(map[string]*pb.ListHostsResponseHost)
-	for _, query := range queries {
-		for _, host := range query.OutputHosts {
+	for _, set := range sets {
+		for _, host := range set.hosts {
 			if existingHost, ok := hostResultMap[host.ExternalId]; ok {
-				// Existing host, just add the set ID to the list of seen IDs
-				// and continue
-				existingHost.SetIds = append(existingHost.SetIds, query.Id)
+				// Existing host, merge it and add the set ID to the list
+				// of seen IDs. A host can be seen more than once for the
+				// same set when the set has several filters.
+				mergeHost(existingHost, host, set.setId)
+				if !stringInSlice(existingHost.SetIds, set.setId) {
+					existingHost.SetIds = append(existingHost.SetIds, set.setId)
+				}
 				continue
 			}
 
 			// This will be the first seen entry, so append the set ID to
 			// this host, and add it.
-			host.SetIds = append(host.SetIds, query.Id)
+			host.SetIds = append(host.SetIds, set.setId)
 			hostResultSlice = append(hostResultSlice, host)
 			hostResultMap[host.ExternalId] = host
 		}
 	}
+	return hostResultSlice
+}
 
-	return &pb.ListHostsResponse{
-		Hosts: hostResultSlice,
-	}, nil
+// mergeHost adds the addresses, DNS names and attributes of a host found
+// again for a set to the host found first. A host has a single value per
+// attribute, so for conflicting attributes, such as the ports of two
+// network endpoint groups, the value found first is kept.
+func mergeHost(existing, host *pb.ListHostsResponseHost, setId string) {
+	for _, addr := range host.IpAddresses {
+		addr := addr
+		existing.IpAddresses = appendDistinct(existing.IpAddresses, &addr)
+	}
+	for _, name := range host.DnsNames {
+		name := name
+		existing.DnsNames = appendDistinct(existing.DnsNames, &name)
+	}
+
+	if len(host.GetAttributes().GetFields()) == 0 {
+		return
+	}
+	if existing.Attributes == nil {
+		existing.Attributes = &structpb.Struct{Fields: make(map[string]*structpb.Value)}
+	}
+	for k, v := range host.Attributes.Fields {
+		current, ok := existing.Attributes.Fields[k]
+		if !ok {
+			existing.Attributes.Fields[k] = v
+			continue
+		}
+		if !proto.Equal(current, v) {
+			slog.Warn("conflicting host attribute across host sets, keeping the first value", "host_set_id", setId, "host", existing.ExternalId, "attribute", k)
+		}
+	}
+}
+
+// keepHost applies the missing address policy to a host of the set,
+// reporting whether the host is kept. Instances still provisioning, or
+// with their interfaces detached, have no address Boundary could connect
+// to.
+func keepHost(host *pb.ListHostsResponseHost, onMissingAddress, setId string) (bool, error) {
+	if len(host.IpAddresses) > 0 {
+		return true, nil
+	}
+	switch onMissingAddress {
+	case ConstMissingAddressSkip:
+		slog.Info("skipping host without addresses", "host_set_id", setId, "host", host.ExternalId)
+		return false, nil
+	case ConstMissingAddressError:
+		return false, status.Errorf(codes.InvalidArgument, "host %s in host set id %q has no addresses", host.ExternalId, setId)
+	}
+	return true, nil
 }
 
 func validateSet(s *hostsets.HostSet) error {
@@ -248,6 +504,7 @@ func validateSet(s *hostsets.HostSet) error {
 	}
 	var attrs SetAttributes
 	attrMap := s.GetAttributes().AsMap()
+	normalizeSliceFields(attrMap)
 	if err := mapstructure.Decode(attrMap, &attrs); err != nil {
 		return status.Errorf(codes.InvalidArgument, "error decoding set attributes: %s", err)
 	}
@@ -256,12 +513,226 @@ func validateSet(s *hostsets.HostSet) error {
 	_, filterSet := attrMap[ConstListInstancesFilter]
 	_, instanceGroupSet := attrMap[ConstInstanceGroup]
 
-	if instanceGroupSet && filterSet {
-		badFields["attributes"] = "must set instance group or filter, cannot set both"
+	_, filtersSet := attrMap[ConstListInstancesFilters]
+
+	if filterSet && filtersSet {
+		badFields["attributes"] = "must set filter or filters, cannot set both"
 	} else if instanceGroupSet && len(attrs.InstanceGroup) == 0 {
 		badFields[fmt.Sprintf("attributes.%s", ConstInstanceGroup)] = "must not be empty."
 	} else if filterSet && len(attrs.Filter) == 0 {
 		badFields[fmt.Sprintf("attributes.%s", ConstListInstancesFilter)] = "must not be empty."
+	} else if filtersSet && len(attrs.Filters) == 0 {
+		badFields[fmt.Sprintf("attributes.%s", ConstListInstancesFilters)] = "must not be empty."
+	}
+	var sources []string
+	for _, f := range sourceSetFields {
+		if _, ok := attrMap[f]; ok {
+			sources = append(sources, f)
+		}
+	}
+	if len(sources) > 1 {
+		badFields["attributes"] = fmt.Sprintf("must set at most one of %s, got %s.", strings.Join(sourceSetFields, ", "), strings.Join(sources, " and "))
+	}
+	if _, ok := attrMap[ConstInstances]; ok {
+		switch {
+		case len(attrs.Instances) == 0:
+			badFields[fmt.Sprintf("attributes.%s", ConstInstances)] = "must not be empty."
+		case filterSet || filtersSet:
+			badFields[fmt.Sprintf("attributes.%s", ConstInstances)] = fmt.Sprintf("cannot be combined with %s or %s.", ConstListInstancesFilter, ConstListInstancesFilters)
+		}
+		for _, ref := range attrs.Instances {
+			if len(ref) == 0 {
+				badFields[fmt.Sprintf("attributes.%s", ConstInstances)] = "must not contain empty instance names."
+			}
+		}
+	}
+	if len(attrs.Filter) > 0 {
+		if err := validateFilter(attrs.Filter); err != nil {
+			badFields[fmt.Sprintf("attributes.%s", ConstListInstancesFilter)] = fmt.Sprintf("invalid filter: %s.", err)
+		}
+	}
+	for i, expression := range attrs.Filters {
+		if len(expression) == 0 {
+			badFields[fmt.Sprintf("attributes.%s", ConstListInstancesFilters)] = "must not contain empty filters."
+			continue
+		}
+		if err := validateFilter(expression); err != nil {
+			badFields[fmt.Sprintf("attributes.%s", ConstListInstancesFilters)] = fmt.Sprintf("invalid filter %d: %s.", i, err)
+		}
+	}
+
+	for _, labelField := range []string{ConstLabels, ConstExcludeLabels} {
+		labels, err := values.GetMapStringString(s.GetAttributes(), labelField, false)
+		if err != nil {
+			badFields[fmt.Sprintf("attributes.%s", labelField)] = err.Error()
+			continue
+		}
+		if err := validateLabels(labels); err != nil {
+			badFields[fmt.Sprintf("attributes.%s", labelField)] = err.Error()
+		}
+	}
+
+	switch attrs.NetworkTagsMatch {
+	case "", ConstMatchAny, ConstMatchAll:
+	default:
+		badFields[fmt.Sprintf("attributes.%s", ConstNetworkTagsMatch)] = fmt.Sprintf("must be %s or %s.", ConstMatchAny, ConstMatchAll)
+	}
+	if _, ok := attrMap[ConstNetworkTagsMatch]; ok && len(attrs.NetworkTags) == 0 {
+		badFields[fmt.Sprintf("attributes.%s", ConstNetworkTagsMatch)] = fmt.Sprintf("requires %s to be set.", ConstNetworkTags)
+	}
+
+	if _, err := values.GetMapStringString(s.GetAttributes(), ConstMetadata, false); err != nil {
+		badFields[fmt.Sprintf("attributes.%s", ConstMetadata)] = err.Error()
+	}
+	for _, k := range attrs.MetadataKeys {
+		if len(k) == 0 {
+			badFields[fmt.Sprintf("attributes.%s", ConstMetadataKeys)] = "must not contain empty keys."
+		}
+	}
+
+	for _, durationField := range []string{ConstMinUptime, ConstMinAge, ConstMaxAge, ConstMaintenanceHorizon} {
+		if _, err := values.GetDurationValue(s.GetAttributes(), durationField); err != nil {
+			badFields[fmt.Sprintf("attributes.%s", durationField)] = err.Error()
+		}
+	}
+
+	if _, ok := attrMap[ConstRequireGuestAttribute]; ok {
+		if _, _, err := parseGuestAttribute(attrs.RequireGuestAttribute); err != nil {
+			badFields[fmt.Sprintf("attributes.%s", ConstRequireGuestAttribute)] = fmt.Sprintf("%s.", err)
+		}
+	}
+
+	if _, ok := attrMap[ConstBexprFilter]; ok {
+		if len(attrs.BexprFilter) == 0 {
+			badFields[fmt.Sprintf("attributes.%s", ConstBexprFilter)] = "must not be empty."
+		} else if _, err := newBexprEvaluator(attrs.BexprFilter); err != nil {
+			badFields[fmt.Sprintf("attributes.%s", ConstBexprFilter)] = fmt.Sprintf("invalid bexpr filter: %s.", err)
+		}
+	}
+
+	if _, ok := attrMap[ConstNetwork]; ok && len(attrs.Network) == 0 {
+		badFields[fmt.Sprintf("attributes.%s", ConstNetwork)] = "must not be empty."
+	}
+	if _, ok := attrMap[ConstSubnetwork]; ok && len(attrs.Subnetwork) == 0 {
+		badFields[fmt.Sprintf("attributes.%s", ConstSubnetwork)] = "must not be empty."
+	}
+	if _, ok := attrMap[ConstNetworkEndpointGroup]; ok && len(attrs.NetworkEndpointGroup) == 0 {
+		badFields[fmt.Sprintf("attributes.%s", ConstNetworkEndpointGroup)] = "must not be empty."
+	}
+	if _, ok := attrMap[ConstBackendService]; ok && len(attrs.BackendService) == 0 {
+		badFields[fmt.Sprintf("attributes.%s", ConstBackendService)] = "must not be empty."
+	}
+	for _, f := range []string{ConstBackendServiceRegion, ConstHealthyOnly} {
+		if _, ok := attrMap[f]; ok && len(attrs.BackendService) == 0 {
+			badFields[fmt.Sprintf("attributes.%s", f)] = fmt.Sprintf("requires %s to be set.", ConstBackendService)
+		}
+	}
+	if _, ok := attrMap[ConstGKECluster]; ok && len(attrs.GKECluster) == 0 {
+		badFields[fmt.Sprintf("attributes.%s", ConstGKECluster)] = "must not be empty."
+	}
+	if _, ok := attrMap[ConstGKENodePool]; ok {
+		switch {
+		case len(attrs.GKECluster) == 0:
+			badFields[fmt.Sprintf("attributes.%s", ConstGKENodePool)] = fmt.Sprintf("requires %s to be set.", ConstGKECluster)
+		case len(attrs.GKENodePool) == 0:
+			badFields[fmt.Sprintf("attributes.%s", ConstGKENodePool)] = "must not be empty."
+		}
+	}
+	if _, ok := attrMap[ConstDataprocCluster]; ok && len(attrs.DataprocCluster) == 0 {
+		badFields[fmt.Sprintf("attributes.%s", ConstDataprocCluster)] = "must not be empty."
+	}
+	if _, ok := attrMap[ConstRoles]; ok && len(attrs.DataprocCluster) == 0 {
+		badFields[fmt.Sprintf("attributes.%s", ConstRoles)] = fmt.Sprintf("requires %s to be set.", ConstDataprocCluster)
+	}
+	for _, role := range attrs.Roles {
+		if _, ok := allowedRoles[role]; !ok {
+			badFields[fmt.Sprintf("attributes.%s", ConstRoles)] = fmt.Sprintf("unknown role %q, must be one of %s, %s or %s.", role, ConstRoleMaster, ConstRoleWorker, ConstRoleSecondaryWorker)
+		}
+	}
+	if _, ok := attrMap[ConstServiceDirectory]; ok {
+		if err := validateServiceDirectoryService(attrs.ServiceDirectory); err != nil {
+			badFields[fmt.Sprintf("attributes.%s", ConstServiceDirectory)] = fmt.Sprintf("%s.", err)
+		}
+		for f := range attrMap {
+			if _, ok := serviceDirectorySetFields[f]; !ok {
+				badFields[fmt.Sprintf("attributes.%s", f)] = fmt.Sprintf("not supported with %s.", ConstServiceDirectory)
+			}
+		}
+	}
+	if _, ok := attrMap[ConstAnnotations]; ok {
+		if _, err := values.GetMapStringString(s.GetAttributes(), ConstAnnotations, false); err != nil {
+			badFields[fmt.Sprintf("attributes.%s", ConstAnnotations)] = err.Error()
+		} else if len(attrs.ServiceDirectory) == 0 {
+			badFields[fmt.Sprintf("attributes.%s", ConstAnnotations)] = fmt.Sprintf("requires %s to be set.", ConstServiceDirectory)
+		}
+	}
+	if _, ok := attrMap[ConstFirewallRule]; ok && len(attrs.FirewallRule) == 0 {
+		badFields[fmt.Sprintf("attributes.%s", ConstFirewallRule)] = "must not be empty."
+	}
+	if _, ok := attrMap[ConstFirewallRuleInterfaceOnly]; ok {
+		switch {
+		case len(attrs.FirewallRule) == 0:
+			badFields[fmt.Sprintf("attributes.%s", ConstFirewallRuleInterfaceOnly)] = fmt.Sprintf("requires %s to be set.", ConstFirewallRule)
+		case len(attrs.Network) > 0:
+			badFields[fmt.Sprintf("attributes.%s", ConstFirewallRuleInterfaceOnly)] = fmt.Sprintf("cannot be combined with %s.", ConstNetwork)
+		}
+	}
+	if _, ok := attrMap[ConstTags]; ok && len(attrs.Tags) == 0 {
+		badFields[fmt.Sprintf("attributes.%s", ConstTags)] = "must not be empty."
+	}
+	for _, tag := range attrs.Tags {
+		if err := validateTag(tag); err != nil {
+			badFields[fmt.Sprintf("attributes.%s", ConstTags)] = fmt.Sprintf("%s.", err)
+		}
+	}
+	if _, ok := attrMap[ConstInstanceTemplate]; ok && len(attrs.InstanceTemplate) == 0 {
+		badFields[fmt.Sprintf("attributes.%s", ConstInstanceTemplate)] = "must not be empty."
+	}
+
+	for _, kind := range attrs.AddressKinds {
+		if _, ok := allowedAddressKinds[kind]; !ok {
+			badFields[fmt.Sprintf("attributes.%s", ConstAddressKinds)] = fmt.Sprintf("unknown address kind %q, must be one of %s, %s or %s.", kind, ConstAddressKindInternal, ConstAddressKindExternal, ConstAddressKindAlias)
+		}
+	}
+	for _, family := range attrs.AddressFamilies {
+		if _, ok := allowedAddressFamilies[family]; !ok {
+			badFields[fmt.Sprintf("attributes.%s", ConstAddressFamilies)] = fmt.Sprintf("unknown address family %q, must be %s or %s.", family, ConstAddressFamilyIPv4, ConstAddressFamilyIPv6)
+		}
+	}
+
+	if _, ok := attrMap[ConstOnMissingAddress]; ok {
+		if err := validateMissingAddressPolicy(attrs.OnMissingAddress); err != nil {
+			badFields[fmt.Sprintf("attributes.%s", ConstOnMissingAddress)] = fmt.Sprintf("%s.", err)
+		}
+	}
+
+	if _, ok := attrMap[ConstStatuses]; ok {
+		if len(attrs.Statuses) == 0 {
+			badFields[fmt.Sprintf("attributes.%s", ConstStatuses)] = "must not be empty."
+		} else if err := validateStatuses(attrs.Statuses); err != nil {
+			badFields[fmt.Sprintf("attributes.%s", ConstStatuses)] = fmt.Sprintf("%s.", err)
+		}
+	}
+
+	for _, model := range attrs.ProvisioningModels {
+		if _, ok := allowedProvisioningModels[model]; !ok {
+			badFields[fmt.Sprintf("attributes.%s", ConstProvisioningModels)] = fmt.Sprintf("unknown provisioning model %q, must be %s or %s.", model, ConstProvisioningModelStandard, ConstProvisioningModelSpot)
+		}
+	}
+
+	switch attrs.Type {
+	case "", ConstSetTypeCompute:
+		if _, ok := attrMap[ConstNamePrefix]; ok {
+			badFields[fmt.Sprintf("attributes.%s", ConstNamePrefix)] = fmt.Sprintf("only supported for type %s.", ConstSetTypeCloudSQL)
+		}
+	case ConstSetTypeCloudSQL, ConstSetTypeTPU:
+		for f := range attrMap {
+			if _, ok := typeSetFields[attrs.Type][f]; !ok {
+				badFields[fmt.Sprintf("attributes.%s", f)] = fmt.Sprintf("not supported for type %s.", attrs.Type)
+			}
+		}
+	default:
+		badFields[fmt.Sprintf("attributes.%s", ConstSetType)] = fmt.Sprintf("must be %s, %s or %s.", ConstSetTypeCompute, ConstSetTypeCloudSQL, ConstSetTypeTPU)
 	}
 
 	for f := range attrMap {
@@ -275,3 +746,45 @@ func validateSet(s *hostsets.HostSet) error {
 	}
 	return nil
 }
+
+// validateSetInstances checks that every instance named in the set
+// exists. Sets without named instances are not checked, so no client is
+// created for them.
+func validateSetInstances(ctx context.Context, catalog *hostcatalogs.HostCatalog, s *hostsets.HostSet) error {
+	setAttrs, err := getSetAttributes(s.GetAttributes())
+	if err != nil {
+		return err
+	}
+	if len(setAttrs.Instances) == 0 {
+		return nil
+	}
+
+	if catalog == nil {
+		return status.Error(codes.InvalidArgument, "catalog is nil")
+	}
+	catalogAttributes, err := getCatalogAttributes(catalog.GetAttributes())
+	if err != nil {
+		return err
+	}
+
+	instancesClient, err := compute.NewInstancesRESTClient(ctx)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "error creating NewInstancesRESTClient: %s", err)
+	}
+	defer instancesClient.Close()
+
+	gclient := GoogleClient{
+		InstancesClient: instancesClient,
+		Context:         ctx,
+	}
+	missing, err := gclient.missingInstances(setAttrs.Instances, catalogAttributes)
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		return errors.InvalidArgumentError("Invalid arguments in the new set", map[string]string{
+			fmt.Sprintf("attributes.%s", ConstInstances): fmt.Sprintf("instances not found: %s.", strings.Join(missing, ", ")),
+		})
+	}
+	return nil
+}
@@ -9,12 +9,14 @@ import (
 	"path/filepath"
 	"testing"
 
+	computepb "cloud.google.com/go/compute/apiv1/computepb"
 	"github.com/hashicorp/boundary/sdk/pbs/controller/api/resources/hostcatalogs"
 	"github.com/hashicorp/boundary/sdk/pbs/controller/api/resources/hostsets"
 	pb "github.com/hashicorp/boundary/sdk/pbs/plugin"
 	"github.com/hashicorp/go-secure-stdlib/parseutil"
 	cred "github.com/joatmon08/boundary-plugin-google/internal/credential"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/structpb"
 )
 
@@ -453,6 +455,100 @@ func TestUpdateCatalog(t *testing.T) {
 	}
 }
 
+func TestOnUpdateCatalog(t *testing.T) {
+	ctx := context.Background()
+	p := &GooglePlugin{}
+
+	current := &hostcatalogs.HostCatalog{
+		Attrs: &hostcatalogs.HostCatalog_Attributes{
+			Attributes: wrapMap(t, map[string]interface{}{
+				cred.ConstProject: "test-project",
+				cred.ConstZone:    "us-central1-a",
+			}),
+		},
+	}
+
+	cases := []struct {
+		name        string
+		req         *pb.OnUpdateCatalogRequest
+		expectedErr string
+	}{
+		{
+			name:        "nil current catalog",
+			req:         &pb.OnUpdateCatalogRequest{},
+			expectedErr: "current catalog is nil",
+		},
+		{
+			name: "nil new catalog",
+			req: &pb.OnUpdateCatalogRequest{
+				CurrentCatalog: current,
+			},
+			expectedErr: "new catalog is nil",
+		},
+		{
+			name: "nil new attributes",
+			req: &pb.OnUpdateCatalogRequest{
+				CurrentCatalog: current,
+				NewCatalog:     &hostcatalogs.HostCatalog{},
+			},
+			expectedErr: "new catalog missing attributes",
+		},
+		{
+			name: "invalid default statuses",
+			req: &pb.OnUpdateCatalogRequest{
+				CurrentCatalog: current,
+				NewCatalog: &hostcatalogs.HostCatalog{
+					Attrs: &hostcatalogs.HostCatalog_Attributes{
+						Attributes: wrapMap(t, map[string]interface{}{
+							cred.ConstProject:    "test-project",
+							cred.ConstZone:       "us-central1-a",
+							ConstDefaultStatuses: []interface{}{},
+						}),
+					},
+				},
+			},
+			expectedErr: "attributes.default_statuses: must not be empty",
+		},
+		{
+			name: "invalid missing address policy",
+			req: &pb.OnUpdateCatalogRequest{
+				CurrentCatalog: current,
+				NewCatalog: &hostcatalogs.HostCatalog{
+					Attrs: &hostcatalogs.HostCatalog_Attributes{
+						Attributes: wrapMap(t, map[string]interface{}{
+							cred.ConstProject:     "test-project",
+							cred.ConstZone:        "us-central1-a",
+							ConstOnMissingAddress: "drop",
+						}),
+					},
+				},
+			},
+			expectedErr: "attributes.on_missing_address: must be include, skip or error",
+		},
+		{
+			name: "valid attributes",
+			req: &pb.OnUpdateCatalogRequest{
+				CurrentCatalog: current,
+				NewCatalog:     current,
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			_, err := p.OnUpdateCatalog(ctx, tc.req)
+			if tc.expectedErr != "" {
+				require.ErrorContains(err, tc.expectedErr)
+				return
+			}
+			require.NoError(err)
+		})
+	}
+}
+
 func TestCreateSet(t *testing.T) {
 	ctx := context.Background()
 	p := &GooglePlugin{}
@@ -484,7 +580,7 @@ func TestCreateSet(t *testing.T) {
 			expectedErr: "set is nil",
 		},
 		{
-			name: "only allow instance group or filter, not both",
+			name: "good instance group with filter",
 			req: &pb.OnCreateSetRequest{
 				Set: &hostsets.HostSet{
 					Attrs: &hostsets.HostSet_Attributes{
@@ -497,7 +593,6 @@ func TestCreateSet(t *testing.T) {
 					},
 				},
 			},
-			expectedErr: "attributes: must set instance group or filter",
 		},
 		{
 			name: "empty filter",
@@ -529,6 +624,436 @@ func TestCreateSet(t *testing.T) {
 			},
 			expectedErr: "attributes.instance_group: must not be empty",
 		},
+		{
+			name: "unknown address kind",
+			req: &pb.OnCreateSetRequest{
+				Set: &hostsets.HostSet{
+					Attrs: &hostsets.HostSet_Attributes{
+						Attributes: wrapMap(t, map[string]interface{}{
+							ConstAddressKinds: []interface{}{ConstAddressKindInternal, "public"},
+						}),
+					},
+				},
+			},
+			expectedErr: "attributes.address_kinds: unknown address kind \"public\"",
+		},
+		{
+			name: "good address families",
+			req: &pb.OnCreateSetRequest{
+				Set: &hostsets.HostSet{
+					Attrs: &hostsets.HostSet_Attributes{
+						Attributes: wrapMap(t, map[string]interface{}{
+							ConstAddressFamilies: ConstAddressFamilyIPv6,
+						}),
+					},
+				},
+			},
+		},
+		{
+			name: "invalid label key",
+			req: &pb.OnCreateSetRequest{
+				Set: &hostsets.HostSet{
+					Attrs: &hostsets.HostSet_Attributes{
+						Attributes: wrapMap(t, map[string]interface{}{
+							ConstLabels: map[string]interface{}{
+								"Env": "prod",
+							},
+						}),
+					},
+				},
+			},
+			expectedErr: "attributes.labels: invalid label key \"Env\"",
+		},
+		{
+			name: "good labels with filter",
+			req: &pb.OnCreateSetRequest{
+				Set: &hostsets.HostSet{
+					Attrs: &hostsets.HostSet_Attributes{
+						Attributes: wrapMap(t, map[string]interface{}{
+							ConstListInstancesFilter: "status=RUNNING",
+							ConstLabels: map[string]interface{}{
+								"env": "prod",
+							},
+							ConstExcludeLabels: map[string]interface{}{
+								"team": "payments",
+							},
+						}),
+					},
+				},
+			},
+		},
+		{
+			name: "unknown network tags match",
+			req: &pb.OnCreateSetRequest{
+				Set: &hostsets.HostSet{
+					Attrs: &hostsets.HostSet_Attributes{
+						Attributes: wrapMap(t, map[string]interface{}{
+							ConstNetworkTags:      "ssh",
+							ConstNetworkTagsMatch: "some",
+						}),
+					},
+				},
+			},
+			expectedErr: "attributes.network_tags_match: must be any or all",
+		},
+		{
+			name: "only allow filter or filters, not both",
+			req: &pb.OnCreateSetRequest{
+				Set: &hostsets.HostSet{
+					Attrs: &hostsets.HostSet_Attributes{
+						Attributes: wrapMap(t, map[string]interface{}{
+							ConstListInstancesFilter:  "status=RUNNING",
+							ConstListInstancesFilters: []interface{}{"name=web-*"},
+						}),
+					},
+				},
+			},
+			expectedErr: "attributes: must set filter or filters, cannot set both",
+		},
+		{
+			name: "empty filter in filters",
+			req: &pb.OnCreateSetRequest{
+				Set: &hostsets.HostSet{
+					Attrs: &hostsets.HostSet_Attributes{
+						Attributes: wrapMap(t, map[string]interface{}{
+							ConstListInstancesFilters: []interface{}{"name=web-*", ""},
+						}),
+					},
+				},
+			},
+			expectedErr: "attributes.filters: must not contain empty filters",
+		},
+		{
+			name: "good filters",
+			req: &pb.OnCreateSetRequest{
+				Set: &hostsets.HostSet{
+					Attrs: &hostsets.HostSet_Attributes{
+						Attributes: wrapMap(t, map[string]interface{}{
+							ConstListInstancesFilters: []interface{}{"name=web-*", "labels.role=bastion"},
+						}),
+					},
+				},
+			},
+		},
+		{
+			name: "filter with unknown field",
+			req: &pb.OnCreateSetRequest{
+				Set: &hostsets.HostSet{
+					Attrs: &hostsets.HostSet_Attributes{
+						Attributes: wrapMap(t, map[string]interface{}{
+							ConstListInstancesFilter: "status = RUNNING AND lables.env = prod",
+						}),
+					},
+				},
+			},
+			expectedErr: "attributes.filter: invalid filter: unknown field \"lables.env\" at position 21",
+		},
+		{
+			name: "filter with unbalanced quotes",
+			req: &pb.OnCreateSetRequest{
+				Set: &hostsets.HostSet{
+					Attrs: &hostsets.HostSet_Attributes{
+						Attributes: wrapMap(t, map[string]interface{}{
+							ConstListInstancesFilters: []interface{}{"name = web-1", "name = \"web-2"},
+						}),
+					},
+				},
+			},
+			expectedErr: "attributes.filters: invalid filter 1: unterminated quoted string at position 7",
+		},
+		{
+			name: "invalid bexpr filter",
+			req: &pb.OnCreateSetRequest{
+				Set: &hostsets.HostSet{
+					Attrs: &hostsets.HostSet_Attributes{
+						Attributes: wrapMap(t, map[string]interface{}{
+							ConstBexprFilter: `labels.tier == `,
+						}),
+					},
+				},
+			},
+			expectedErr: "attributes.bexpr_filter: invalid bexpr filter",
+		},
+		{
+			name: "good bexpr filter",
+			req: &pb.OnCreateSetRequest{
+				Set: &hostsets.HostSet{
+					Attrs: &hostsets.HostSet_Attributes{
+						Attributes: wrapMap(t, map[string]interface{}{
+							ConstBexprFilter: `"boundary-role" in metadata_keys`,
+						}),
+					},
+				},
+			},
+		},
+		{
+			name: "empty instances",
+			req: &pb.OnCreateSetRequest{
+				Set: &hostsets.HostSet{
+					Attrs: &hostsets.HostSet_Attributes{
+						Attributes: wrapMap(t, map[string]interface{}{
+							ConstInstances: []interface{}{},
+						}),
+					},
+				},
+			},
+			expectedErr: "attributes.instances: must not be empty",
+		},
+		{
+			name: "instances with instance group",
+			req: &pb.OnCreateSetRequest{
+				Set: &hostsets.HostSet{
+					Attrs: &hostsets.HostSet_Attributes{
+						Attributes: wrapMap(t, map[string]interface{}{
+							ConstInstances:     []interface{}{"web-1"},
+							ConstInstanceGroup: "test",
+						}),
+					},
+				},
+			},
+			expectedErr: "attributes: must set at most one of instance_group, instances, network_endpoint_group, backend_service, gke_cluster, dataproc_cluster, service_directory, got instance_group and instances",
+		},
+		{
+			name: "unknown provisioning model",
+			req: &pb.OnCreateSetRequest{
+				Set: &hostsets.HostSet{
+					Attrs: &hostsets.HostSet_Attributes{
+						Attributes: wrapMap(t, map[string]interface{}{
+							ConstProvisioningModels: []interface{}{"STANDARD", "spot"},
+							ConstMaintenanceHorizon: "24h",
+						}),
+					},
+				},
+			},
+			expectedErr: "attributes.provisioning_models: unknown provisioning model \"spot\", must be STANDARD or SPOT",
+		},
+		{
+			name: "unknown status",
+			req: &pb.OnCreateSetRequest{
+				Set: &hostsets.HostSet{
+					Attrs: &hostsets.HostSet_Attributes{
+						Attributes: wrapMap(t, map[string]interface{}{
+							ConstStatuses: []interface{}{"RUNNING", "STARTED"},
+						}),
+					},
+				},
+			},
+			expectedErr: "attributes.statuses: unknown instance status \"STARTED\"",
+		},
+		{
+			name: "node pool without cluster",
+			req: &pb.OnCreateSetRequest{
+				Set: &hostsets.HostSet{
+					Attrs: &hostsets.HostSet_Attributes{
+						Attributes: wrapMap(t, map[string]interface{}{
+							ConstGKENodePool: "default-pool",
+						}),
+					},
+				},
+			},
+			expectedErr: "attributes.gke_node_pool: requires gke_cluster to be set",
+		},
+		{
+			name: "unknown dataproc role",
+			req: &pb.OnCreateSetRequest{
+				Set: &hostsets.HostSet{
+					Attrs: &hostsets.HostSet_Attributes{
+						Attributes: wrapMap(t, map[string]interface{}{
+							ConstDataprocCluster: "etl",
+							ConstRoles:           []interface{}{ConstRoleMaster, "driver"},
+						}),
+					},
+				},
+			},
+			expectedErr: "attributes.roles: unknown role \"driver\"",
+		},
+		{
+			name: "roles without dataproc cluster",
+			req: &pb.OnCreateSetRequest{
+				Set: &hostsets.HostSet{
+					Attrs: &hostsets.HostSet_Attributes{
+						Attributes: wrapMap(t, map[string]interface{}{
+							ConstRoles: ConstRoleMaster,
+						}),
+					},
+				},
+			},
+			expectedErr: "attributes.roles: requires dataproc_cluster to be set",
+		},
+		{
+			name: "service directory without service",
+			req: &pb.OnCreateSetRequest{
+				Set: &hostsets.HostSet{
+					Attrs: &hostsets.HostSet_Attributes{
+						Attributes: wrapMap(t, map[string]interface{}{
+							ConstServiceDirectory: "prod",
+						}),
+					},
+				},
+			},
+			expectedErr: "attributes.service_directory: invalid service \"prod\", must be of the form NAMESPACE/SERVICE",
+		},
+		{
+			name: "service directory with filter",
+			req: &pb.OnCreateSetRequest{
+				Set: &hostsets.HostSet{
+					Attrs: &hostsets.HostSet_Attributes{
+						Attributes: wrapMap(t, map[string]interface{}{
+							ConstServiceDirectory:    "prod/api",
+							ConstListInstancesFilter: "status=RUNNING",
+						}),
+					},
+				},
+			},
+			expectedErr: "attributes.filter: not supported with service_directory",
+		},
+		{
+			name: "annotations without service directory",
+			req: &pb.OnCreateSetRequest{
+				Set: &hostsets.HostSet{
+					Attrs: &hostsets.HostSet_Attributes{
+						Attributes: wrapMap(t, map[string]interface{}{
+							ConstAnnotations: map[string]interface{}{"tier": "web"},
+						}),
+					},
+				},
+			},
+			expectedErr: "attributes.annotations: requires service_directory to be set",
+		},
+		{
+			name: "good service directory",
+			req: &pb.OnCreateSetRequest{
+				Set: &hostsets.HostSet{
+					Attrs: &hostsets.HostSet_Attributes{
+						Attributes: wrapMap(t, map[string]interface{}{
+							ConstServiceDirectory: "projects/test-project/locations/us-central1/namespaces/prod/services/api",
+							ConstAnnotations:      map[string]interface{}{"tier": "web"},
+						}),
+					},
+				},
+			},
+		},
+		{
+			name: "firewall rule interface only with network",
+			req: &pb.OnCreateSetRequest{
+				Set: &hostsets.HostSet{
+					Attrs: &hostsets.HostSet_Attributes{
+						Attributes: wrapMap(t, map[string]interface{}{
+							ConstFirewallRule:              "allow-ssh",
+							ConstFirewallRuleInterfaceOnly: true,
+							ConstNetwork:                   "default",
+						}),
+					},
+				},
+			},
+			expectedErr: "attributes.firewall_rule_interface_only: cannot be combined with network",
+		},
+		{
+			name: "firewall rule interface only without rule",
+			req: &pb.OnCreateSetRequest{
+				Set: &hostsets.HostSet{
+					Attrs: &hostsets.HostSet_Attributes{
+						Attributes: wrapMap(t, map[string]interface{}{
+							ConstFirewallRuleInterfaceOnly: true,
+						}),
+					},
+				},
+			},
+			expectedErr: "attributes.firewall_rule_interface_only: requires firewall_rule to be set",
+		},
+		{
+			name: "invalid secure tag",
+			req: &pb.OnCreateSetRequest{
+				Set: &hostsets.HostSet{
+					Attrs: &hostsets.HostSet_Attributes{
+						Attributes: wrapMap(t, map[string]interface{}{
+							ConstTags: []interface{}{"env/prod", "tagValues/"},
+						}),
+					},
+				},
+			},
+			expectedErr: "attributes.tags: invalid tag \"tagValues/\", must not contain empty segments",
+		},
+		{
+			name: "good secure tags",
+			req: &pb.OnCreateSetRequest{
+				Set: &hostsets.HostSet{
+					Attrs: &hostsets.HostSet_Attributes{
+						Attributes: wrapMap(t, map[string]interface{}{
+							ConstTags: "123456789/env/prod",
+						}),
+					},
+				},
+			},
+		},
+		{
+			name: "unknown set type",
+			req: &pb.OnCreateSetRequest{
+				Set: &hostsets.HostSet{
+					Attrs: &hostsets.HostSet_Attributes{
+						Attributes: wrapMap(t, map[string]interface{}{
+							ConstSetType: "spanner",
+						}),
+					},
+				},
+			},
+			expectedErr: "attributes.type: must be compute, cloudsql or tpu",
+		},
+		{
+			name: "cloudsql with instance group",
+			req: &pb.OnCreateSetRequest{
+				Set: &hostsets.HostSet{
+					Attrs: &hostsets.HostSet_Attributes{
+						Attributes: wrapMap(t, map[string]interface{}{
+							ConstSetType:       ConstSetTypeCloudSQL,
+							ConstInstanceGroup: "test-group",
+						}),
+					},
+				},
+			},
+			expectedErr: "attributes.instance_group: not supported for type cloudsql",
+		},
+		{
+			name: "name prefix without cloudsql",
+			req: &pb.OnCreateSetRequest{
+				Set: &hostsets.HostSet{
+					Attrs: &hostsets.HostSet_Attributes{
+						Attributes: wrapMap(t, map[string]interface{}{
+							ConstNamePrefix: "db-",
+						}),
+					},
+				},
+			},
+			expectedErr: "attributes.name_prefix: only supported for type cloudsql",
+		},
+		{
+			name: "tpu with name prefix",
+			req: &pb.OnCreateSetRequest{
+				Set: &hostsets.HostSet{
+					Attrs: &hostsets.HostSet_Attributes{
+						Attributes: wrapMap(t, map[string]interface{}{
+							ConstSetType:    ConstSetTypeTPU,
+							ConstNamePrefix: "train-",
+						}),
+					},
+				},
+			},
+			expectedErr: "attributes.name_prefix: not supported for type tpu",
+		},
+		{
+			name: "good cloudsql",
+			req: &pb.OnCreateSetRequest{
+				Set: &hostsets.HostSet{
+					Attrs: &hostsets.HostSet_Attributes{
+						Attributes: wrapMap(t, map[string]interface{}{
+							ConstSetType:    ConstSetTypeCloudSQL,
+							ConstNamePrefix: "db-",
+							ConstLabels:     map[string]interface{}{"env": "prod"},
+						}),
+					},
+				},
+			},
+		},
 		{
 			name: "good filter",
 			req: &pb.OnCreateSetRequest{
@@ -606,7 +1131,7 @@ func TestUpdateSet(t *testing.T) {
 			expectedErr: "set is nil",
 		},
 		{
-			name: "only allow instance group or filter, not both",
+			name: "good instance group with filter",
 			req: &pb.OnUpdateSetRequest{
 				NewSet: &hostsets.HostSet{
 					Attrs: &hostsets.HostSet_Attributes{
@@ -619,7 +1144,6 @@ func TestUpdateSet(t *testing.T) {
 					},
 				},
 			},
-			expectedErr: "attributes: must set instance group or filter",
 		},
 		{
 			name: "empty filter",
@@ -651,6 +1175,72 @@ func TestUpdateSet(t *testing.T) {
 			},
 			expectedErr: "attributes.instance_group: must not be empty",
 		},
+		{
+			name: "filter with unknown field",
+			req: &pb.OnUpdateSetRequest{
+				NewSet: &hostsets.HostSet{
+					Attrs: &hostsets.HostSet_Attributes{
+						Attributes: wrapMap(t, map[string]interface{}{
+							ConstListInstancesFilter: "status = RUNNING AND lables.env = prod",
+						}),
+					},
+				},
+			},
+			expectedErr: "attributes.filter: invalid filter: unknown field \"lables.env\" at position 21",
+		},
+		{
+			name: "filter with unbalanced quotes",
+			req: &pb.OnUpdateSetRequest{
+				NewSet: &hostsets.HostSet{
+					Attrs: &hostsets.HostSet_Attributes{
+						Attributes: wrapMap(t, map[string]interface{}{
+							ConstListInstancesFilters: []interface{}{"name = web-1", "name = \"web-2"},
+						}),
+					},
+				},
+			},
+			expectedErr: "attributes.filters: invalid filter 1: unterminated quoted string at position 7",
+		},
+		{
+			name: "empty instances",
+			req: &pb.OnUpdateSetRequest{
+				NewSet: &hostsets.HostSet{
+					Attrs: &hostsets.HostSet_Attributes{
+						Attributes: wrapMap(t, map[string]interface{}{
+							ConstInstances: []interface{}{},
+						}),
+					},
+				},
+			},
+			expectedErr: "attributes.instances: must not be empty",
+		},
+		{
+			name: "instances with instance group",
+			req: &pb.OnUpdateSetRequest{
+				NewSet: &hostsets.HostSet{
+					Attrs: &hostsets.HostSet_Attributes{
+						Attributes: wrapMap(t, map[string]interface{}{
+							ConstInstances:     []interface{}{"web-1"},
+							ConstInstanceGroup: "test",
+						}),
+					},
+				},
+			},
+			expectedErr: "attributes: must set at most one of instance_group, instances, network_endpoint_group, backend_service, gke_cluster, dataproc_cluster, service_directory, got instance_group and instances",
+		},
+		{
+			name: "unknown missing address policy",
+			req: &pb.OnUpdateSetRequest{
+				NewSet: &hostsets.HostSet{
+					Attrs: &hostsets.HostSet_Attributes{
+						Attributes: wrapMap(t, map[string]interface{}{
+							ConstOnMissingAddress: "ignore",
+						}),
+					},
+				},
+			},
+			expectedErr: "attributes.on_missing_address: must be include, skip or error",
+		},
 		{
 			name: "good filter",
 			req: &pb.OnUpdateSetRequest{
@@ -696,3 +1286,52 @@ func TestUpdateSet(t *testing.T) {
 		})
 	}
 }
+
+func TestDedupeHosts(t *testing.T) {
+	require := require.New(t)
+
+	instance := &computepb.Instance{
+		Name:     proto.String("web-1"),
+		SelfLink: proto.String(testComputeURL + "/zones/us-central1-a/instances/web-1"),
+		NetworkInterfaces: []*computepb.NetworkInterface{
+			{
+				NetworkIP: proto.String("10.0.0.2"),
+				AccessConfigs: []*computepb.AccessConfig{
+					{NatIP: proto.String("34.1.2.3")},
+				},
+			},
+		},
+	}
+	newHost := func(attributes *SetAttributes, endpoints ...*computepb.NetworkEndpoint) *pb.ListHostsResponseHost {
+		host, err := instanceToHost(instance, attributes)
+		require.NoError(err)
+		if len(endpoints) > 0 {
+			applyNetworkEndpoints(host, instance, endpoints, attributes)
+		}
+		return host
+	}
+
+	// The same instance is found by sets selecting different addresses
+	// and ports.
+	internal := &SetAttributes{AddressKinds: []string{ConstAddressKindInternal}}
+	external := &SetAttributes{AddressKinds: []string{ConstAddressKindExternal}}
+	hosts := dedupeHosts([]setHosts{
+		{setId: "internal", hosts: []*pb.ListHostsResponseHost{newHost(internal)}},
+		{setId: "external", hosts: []*pb.ListHostsResponseHost{newHost(external)}},
+		{setId: "neg", hosts: []*pb.ListHostsResponseHost{newHost(&SetAttributes{}, &computepb.NetworkEndpoint{
+			IpAddress: proto.String("10.0.0.3"),
+			Port:      proto.Int32(8443),
+		})}},
+		{setId: "other-neg", hosts: []*pb.ListHostsResponseHost{newHost(&SetAttributes{}, &computepb.NetworkEndpoint{
+			IpAddress: proto.String("10.0.0.3"),
+			Port:      proto.Int32(9443),
+		})}},
+	}, 4)
+
+	require.Len(hosts, 1)
+	require.Equal([]string{"internal", "external", "neg", "other-neg"}, hosts[0].SetIds)
+	require.Equal([]string{"10.0.0.2", "34.1.2.3", "10.0.0.3"}, hosts[0].IpAddresses)
+	require.Equal(map[string]interface{}{
+		ConstHostAttributePort: float64(8443),
+	}, hosts[0].GetAttributes().AsMap())
+}
@@ -0,0 +1,353 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package plugin
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	computepb "cloud.google.com/go/compute/apiv1/computepb"
+	"github.com/joatmon08/boundary-plugin-google/internal/filter"
+)
+
+// instanceTemplateMetadataKey is the metadata item recording the
+// instance template an instance was created from.
+const instanceTemplateMetadataKey = "instance-template"
+
+// timeNow returns the current time, it is replaced in tests.
+var timeNow = time.Now
+
+// selectInstances returns the instances matching the client-side
+// selectors of the set attributes. These are selectors the Compute API
+// filter cannot express, so they are evaluated after listing.
+func selectInstances(instances []*computepb.Instance, attributes *SetAttributes) []*computepb.Instance {
+	selected := make([]*computepb.Instance, 0, len(instances))
+	for _, instance := range instances {
+		if !instanceMatches(instance, attributes) {
+			continue
+		}
+		selected = append(selected, instance)
+	}
+	return selected
+}
+
+// selectByStatus returns the instances in any of the statuses. All
+// instances are returned when there are no statuses.
+func selectByStatus(instances []*computepb.Instance, statuses []string) []*computepb.Instance {
+	if len(statuses) == 0 {
+		return instances
+	}
+
+	selected := make([]*computepb.Instance, 0, len(instances))
+	for _, instance := range instances {
+		if stringInSlice(statuses, instance.GetStatus()) {
+			selected = append(selected, instance)
+		}
+	}
+	return selected
+}
+
+// filterInstances returns the instances matching any of the filters.
+// All instances are returned when there are no filters.
+func filterInstances(instances []*computepb.Instance, filters []*filter.Filter) ([]*computepb.Instance, error) {
+	if len(filters) == 0 {
+		return instances, nil
+	}
+
+	selected := make([]*computepb.Instance, 0, len(instances))
+	for _, instance := range instances {
+		for _, f := range filters {
+			ok, err := f.Matches(instance)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				selected = append(selected, instance)
+				break
+			}
+		}
+	}
+	return selected, nil
+}
+
+// selectConcurrently returns the instances for which match reports true,
+// in their original order. Selectors that need a request per instance use
+// it to run at most limit matches at a time. The first error in instance
+// order is returned.
+func selectConcurrently(instances []*computepb.Instance, limit int, match func(*computepb.Instance) (bool, error)) ([]*computepb.Instance, error) {
+	matches := make([]bool, len(instances))
+	errs := make([]error, len(instances))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, limit)
+	for i, instance := range instances {
+		i, instance := i, instance
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			matches[i], errs[i] = match(instance)
+		}()
+	}
+	wg.Wait()
+
+	selected := make([]*computepb.Instance, 0, len(instances))
+	for i, instance := range instances {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		if matches[i] {
+			selected = append(selected, instance)
+		}
+	}
+	return selected, nil
+}
+
+// instanceMatches reports whether a single instance matches the
+// client-side selectors of the set attributes.
+func instanceMatches(instance *computepb.Instance, attributes *SetAttributes) bool {
+	if attributes == nil {
+		return true
+	}
+
+	if attributes.Network != "" || attributes.Subnetwork != "" {
+		found := false
+		for _, iface := range instance.GetNetworkInterfaces() {
+			if attributes.includesInterface(iface) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	// Labels are usually part of the list filter, but not of filters
+	// using eq or ne.
+	if !labelsMatch(instance.GetLabels(), attributes.Labels, attributes.ExcludeLabels) {
+		return false
+	}
+
+	if len(attributes.NetworkTags) > 0 && !networkTagsMatch(instance, attributes.NetworkTags, attributes.NetworkTagsMatch) {
+		return false
+	}
+
+	if len(attributes.ServiceAccounts) > 0 && !serviceAccountsMatch(instance, attributes.ServiceAccounts) {
+		return false
+	}
+
+	if attributes.MinUptime > 0 && !olderThan(instance.GetLastStartTimestamp(), attributes.MinUptime) {
+		return false
+	}
+
+	if attributes.MinAge > 0 && !olderThan(instance.GetCreationTimestamp(), attributes.MinAge) {
+		return false
+	}
+
+	if attributes.MaxAge > 0 && olderThan(instance.GetCreationTimestamp(), attributes.MaxAge) {
+		return false
+	}
+
+	if len(attributes.ProvisioningModels) > 0 && !stringInSlice(attributes.ProvisioningModels, provisioningModel(instance)) {
+		return false
+	}
+
+	if attributes.ExcludePreemptible && instance.GetScheduling().GetPreemptible() {
+		return false
+	}
+
+	if attributes.MaintenanceHorizon > 0 && maintenanceWithin(instance, attributes.MaintenanceHorizon) {
+		return false
+	}
+
+	if attributes.InstanceTemplate != "" && !templateMatches(instance, attributes.InstanceTemplate) {
+		return false
+	}
+
+	if (len(attributes.Metadata) > 0 || len(attributes.MetadataKeys) > 0) && !metadataMatches(instance, attributes.Metadata, attributes.MetadataKeys) {
+		return false
+	}
+
+	return true
+}
+
+// labelsMatch reports whether the labels include all of the wanted
+// labels and none of the excluded ones. It is used for resources whose
+// labels cannot be selected by a list filter, and for instances, whose
+// labels are not part of the list filter when the filter uses eq or ne.
+func labelsMatch(labels, want, exclude map[string]string) bool {
+	for k, v := range want {
+		if got, ok := labels[k]; !ok || got != v {
+			return false
+		}
+	}
+	for k, v := range exclude {
+		if got, ok := labels[k]; ok && got == v {
+			return false
+		}
+	}
+	return true
+}
+
+// olderThan reports whether the RFC 3339 timestamp is at least d in the
+// past. Missing or malformed timestamps are never older than d.
+func olderThan(timestamp string, d time.Duration) bool {
+	t, err := time.Parse(time.RFC3339Nano, timestamp)
+	if err != nil {
+		return false
+	}
+	return timeNow().Sub(t) >= d
+}
+
+// provisioningModel returns the provisioning model of the instance.
+// Instances without one are standard instances.
+func provisioningModel(instance *computepb.Instance) string {
+	if model := instance.GetScheduling().GetProvisioningModel(); model != "" {
+		return model
+	}
+	return ConstProvisioningModelStandard
+}
+
+// maintenanceWithin reports whether the instance has maintenance
+// scheduled to start within d. Maintenance without a known start time
+// is treated as imminent.
+func maintenanceWithin(instance *computepb.Instance, d time.Duration) bool {
+	maintenance := instance.GetResourceStatus().GetUpcomingMaintenance()
+	if maintenance == nil {
+		return false
+	}
+	start, err := time.Parse(time.RFC3339Nano, maintenance.GetWindowStartTime())
+	if err != nil {
+		return true
+	}
+	return start.Sub(timeNow()) <= d
+}
+
+// templateMatches reports whether the instance-template metadata item of
+// the instance names the wanted instance template. Instances without the
+// item never match, as the compute client does not expose the
+// sourceInstanceTemplate field. The wanted template may be a bare name, a
+// relative path or a full URL, and its name may contain * wildcards. The
+// project is not compared since the metadata records the project number
+// rather than the project ID.
+func templateMatches(instance *computepb.Instance, want string) bool {
+	var template string
+	for _, item := range instance.GetMetadata().GetItems() {
+		if item.GetKey() == instanceTemplateMetadataKey {
+			template = item.GetValue()
+			break
+		}
+	}
+	if template == "" {
+		return false
+	}
+
+	gotScope, gotName := templateScope(template)
+	wantScope, wantName := templateScope(want)
+	if wantScope != "" && wantScope != gotScope {
+		return false
+	}
+	return filter.MatchWildcard(wantName, gotName)
+}
+
+// templateScope splits an instance template reference into its scope,
+// global or regions/<region>, and its name. The scope is empty for bare
+// names.
+func templateScope(template string) (string, string) {
+	parts := strings.Split(resourcePath(template), "/")
+	name := parts[len(parts)-1]
+	for i, part := range parts {
+		if part != "instanceTemplates" {
+			continue
+		}
+		start := 0
+		if len(parts) > 1 && parts[0] == "projects" {
+			start = 2
+		}
+		if start <= i {
+			return strings.Join(parts[start:i], "/"), name
+		}
+	}
+	return "", name
+}
+
+// metadataMatches reports whether the instance metadata has every one of
+// the wanted items and keys. Item values may contain * wildcards.
+func metadataMatches(instance *computepb.Instance, items map[string]string, keys []string) bool {
+	metadata := make(map[string]string, len(instance.GetMetadata().GetItems()))
+	for _, item := range instance.GetMetadata().GetItems() {
+		metadata[item.GetKey()] = item.GetValue()
+	}
+
+	for k, pattern := range items {
+		v, ok := metadata[k]
+		if !ok || !filter.MatchWildcard(pattern, v) {
+			return false
+		}
+	}
+	for _, k := range keys {
+		if _, ok := metadata[k]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// networkTagsMatch reports whether the instance has any, or with the
+// "all" match mode every, of the wanted network tags.
+func networkTagsMatch(instance *computepb.Instance, want []string, match string) bool {
+	tags := instance.GetTags().GetItems()
+	for _, tag := range want {
+		found := stringInSlice(tags, tag)
+		if found && match != ConstMatchAll {
+			return true
+		}
+		if !found && match == ConstMatchAll {
+			return false
+		}
+	}
+	return match == ConstMatchAll
+}
+
+// serviceAccountsMatch reports whether any of the wanted service accounts
+// is attached to the instance.
+func serviceAccountsMatch(instance *computepb.Instance, want []string) bool {
+	for _, sa := range instance.GetServiceAccounts() {
+		for _, email := range want {
+			if strings.EqualFold(sa.GetEmail(), email) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// resourcePath returns the relative resource path of a Compute resource
+// URL, e.g. projects/my-project/global/networks/default. Values that are
+// not URLs are returned as-is.
+func resourcePath(url string) string {
+	if i := strings.Index(url, "projects/"); i >= 0 {
+		return url[i:]
+	}
+	return url
+}
+
+// resourceMatches reports whether the resource URL refers to the wanted
+// resource. The wanted resource may be a bare name, a relative resource
+// path or a full URL. Partial values match on path segment boundaries,
+// so a bare network name matches that network in any project, which
+// includes the host project of a Shared VPC.
+func resourceMatches(url, want string) bool {
+	got := resourcePath(url)
+	want = strings.Trim(resourcePath(want), "/")
+	if got == "" || want == "" {
+		return false
+	}
+	return got == want || strings.HasSuffix(got, "/"+want)
+}
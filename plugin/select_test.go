@@ -0,0 +1,587 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	"cloud.google.com/go/compute/apiv1/computepb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestResourceMatches(t *testing.T) {
+	sharedNetwork := "https://www.googleapis.com/compute/v1/projects/host-project/global/networks/shared-vpc"
+	subnetwork := "https://www.googleapis.com/compute/v1/projects/host-project/regions/us-central1/subnetworks/data"
+
+	cases := []struct {
+		name     string
+		url      string
+		want     string
+		expected bool
+	}{
+		{
+			name:     "bare name",
+			url:      sharedNetwork,
+			want:     "shared-vpc",
+			expected: true,
+		},
+		{
+			name:     "host project path",
+			url:      sharedNetwork,
+			want:     "projects/host-project/global/networks/shared-vpc",
+			expected: true,
+		},
+		{
+			name:     "full url",
+			url:      sharedNetwork,
+			want:     sharedNetwork,
+			expected: true,
+		},
+		{
+			name:     "other project",
+			url:      sharedNetwork,
+			want:     "projects/service-project/global/networks/shared-vpc",
+			expected: false,
+		},
+		{
+			name:     "partial name",
+			url:      sharedNetwork,
+			want:     "vpc",
+			expected: false,
+		},
+		{
+			name:     "region and subnetwork",
+			url:      subnetwork,
+			want:     "regions/us-central1/subnetworks/data",
+			expected: true,
+		},
+		{
+			name:     "empty url",
+			url:      "",
+			want:     "shared-vpc",
+			expected: false,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, resourceMatches(tc.url, tc.want))
+		})
+	}
+}
+
+func TestSelectInstances(t *testing.T) {
+	management := &computepb.Instance{
+		Name:   proto.String("management"),
+		Labels: map[string]string{"env": "prod"},
+		NetworkInterfaces: []*computepb.NetworkInterface{
+			{
+				Network:    proto.String("https://www.googleapis.com/compute/v1/projects/host-project/global/networks/management"),
+				Subnetwork: proto.String("https://www.googleapis.com/compute/v1/projects/host-project/regions/us-central1/subnetworks/management"),
+			},
+		},
+	}
+	multiNic := &computepb.Instance{
+		Name: proto.String("multi-nic"),
+		NetworkInterfaces: []*computepb.NetworkInterface{
+			{
+				Network:    proto.String("https://www.googleapis.com/compute/v1/projects/host-project/global/networks/management"),
+				Subnetwork: proto.String("https://www.googleapis.com/compute/v1/projects/host-project/regions/us-central1/subnetworks/management"),
+			},
+			{
+				Network:    proto.String("https://www.googleapis.com/compute/v1/projects/host-project/global/networks/data"),
+				Subnetwork: proto.String("https://www.googleapis.com/compute/v1/projects/host-project/regions/us-central1/subnetworks/data"),
+			},
+		},
+	}
+	instances := []*computepb.Instance{management, multiNic}
+
+	cases := []struct {
+		name       string
+		attributes *SetAttributes
+		expected   []*computepb.Instance
+	}{
+		{
+			name:     "no selectors",
+			expected: instances,
+		},
+		{
+			name: "network",
+			attributes: &SetAttributes{
+				Network: "data",
+			},
+			expected: []*computepb.Instance{multiNic},
+		},
+		{
+			name: "subnetwork",
+			attributes: &SetAttributes{
+				Subnetwork: "regions/us-central1/subnetworks/management",
+			},
+			expected: instances,
+		},
+		{
+			name: "labels",
+			attributes: &SetAttributes{
+				Labels: map[string]string{"env": "prod"},
+			},
+			expected: []*computepb.Instance{management},
+		},
+		{
+			name: "exclude labels",
+			attributes: &SetAttributes{
+				ExcludeLabels: map[string]string{"env": "prod"},
+			},
+			expected: []*computepb.Instance{multiNic},
+		},
+		{
+			name: "network and mismatched subnetwork",
+			attributes: &SetAttributes{
+				Network:    "data",
+				Subnetwork: "management",
+			},
+			expected: []*computepb.Instance{},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, selectInstances(instances, tc.attributes))
+		})
+	}
+}
+
+func TestSelectInstancesByTagsAndServiceAccounts(t *testing.T) {
+	web := &computepb.Instance{
+		Name: proto.String("web"),
+		Tags: &computepb.Tags{
+			Items: []string{"http-server", "ssh"},
+		},
+		ServiceAccounts: []*computepb.ServiceAccount{
+			{
+				Email: proto.String("web@test-project.iam.gserviceaccount.com"),
+			},
+		},
+	}
+	db := &computepb.Instance{
+		Name: proto.String("db"),
+		Tags: &computepb.Tags{
+			Items: []string{"ssh"},
+		},
+		ServiceAccounts: []*computepb.ServiceAccount{
+			{
+				Email: proto.String("db@test-project.iam.gserviceaccount.com"),
+			},
+		},
+	}
+	untagged := &computepb.Instance{
+		Name: proto.String("untagged"),
+	}
+	instances := []*computepb.Instance{web, db, untagged}
+
+	cases := []struct {
+		name       string
+		attributes *SetAttributes
+		expected   []*computepb.Instance
+	}{
+		{
+			name: "any network tag",
+			attributes: &SetAttributes{
+				NetworkTags: []string{"http-server", "ssh"},
+			},
+			expected: []*computepb.Instance{web, db},
+		},
+		{
+			name: "all network tags",
+			attributes: &SetAttributes{
+				NetworkTags:      []string{"http-server", "ssh"},
+				NetworkTagsMatch: ConstMatchAll,
+			},
+			expected: []*computepb.Instance{web},
+		},
+		{
+			name: "service account",
+			attributes: &SetAttributes{
+				ServiceAccounts: []string{"db@test-project.iam.gserviceaccount.com"},
+			},
+			expected: []*computepb.Instance{db},
+		},
+		{
+			name: "network tag and service account",
+			attributes: &SetAttributes{
+				NetworkTags:     []string{"ssh"},
+				ServiceAccounts: []string{"web@test-project.iam.gserviceaccount.com"},
+			},
+			expected: []*computepb.Instance{web},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, selectInstances(instances, tc.attributes))
+		})
+	}
+}
+
+func TestFilterInstances(t *testing.T) {
+	require := require.New(t)
+
+	web := &computepb.Instance{
+		Name:   proto.String("web"),
+		Status: proto.String("RUNNING"),
+		Labels: map[string]string{
+			"tier": "web",
+		},
+	}
+	stoppedWeb := &computepb.Instance{
+		Name:   proto.String("stopped-web"),
+		Status: proto.String("TERMINATED"),
+		Labels: map[string]string{
+			"tier": "web",
+		},
+	}
+	db := &computepb.Instance{
+		Name:   proto.String("db"),
+		Status: proto.String("RUNNING"),
+		Labels: map[string]string{
+			"tier": "db",
+		},
+	}
+	instances := []*computepb.Instance{web, stoppedWeb, db}
+
+	actual, err := filterInstances(instances, nil)
+	require.NoError(err)
+	require.Equal(instances, actual)
+
+	filters, err := buildInstanceGroupFilters(&SetAttributes{
+		InstanceGroup: "test",
+		Filter:        "status = RUNNING",
+		Labels: map[string]string{
+			"tier": "web",
+		},
+	})
+	require.NoError(err)
+	actual, err = filterInstances(instances, filters)
+	require.NoError(err)
+	require.Equal([]*computepb.Instance{web}, actual)
+
+	filters, err = buildInstanceGroupFilters(&SetAttributes{
+		InstanceGroup: "test",
+		Filters:       []string{"name = db", "status = TERMINATED"},
+	})
+	require.NoError(err)
+	actual, err = filterInstances(instances, filters)
+	require.NoError(err)
+	require.Equal([]*computepb.Instance{stoppedWeb, db}, actual)
+}
+
+func TestSelectInstancesByMetadata(t *testing.T) {
+	dbAdmin := &computepb.Instance{
+		Name: proto.String("db-admin"),
+		Metadata: &computepb.Metadata{
+			Items: []*computepb.Items{
+				{
+					Key:   proto.String("boundary-role"),
+					Value: proto.String("db-admin"),
+				},
+				{
+					Key:   proto.String("enable-oslogin"),
+					Value: proto.String("TRUE"),
+				},
+			},
+		},
+	}
+	webAdmin := &computepb.Instance{
+		Name: proto.String("web-admin"),
+		Metadata: &computepb.Metadata{
+			Items: []*computepb.Items{
+				{
+					Key:   proto.String("boundary-role"),
+					Value: proto.String("web-admin"),
+				},
+			},
+		},
+	}
+	noMetadata := &computepb.Instance{
+		Name: proto.String("no-metadata"),
+	}
+	instances := []*computepb.Instance{dbAdmin, webAdmin, noMetadata}
+
+	cases := []struct {
+		name       string
+		attributes *SetAttributes
+		expected   []*computepb.Instance
+	}{
+		{
+			name: "exact value",
+			attributes: &SetAttributes{
+				Metadata: map[string]string{
+					"boundary-role": "db-admin",
+				},
+			},
+			expected: []*computepb.Instance{dbAdmin},
+		},
+		{
+			name: "glob value",
+			attributes: &SetAttributes{
+				Metadata: map[string]string{
+					"boundary-role": "*-admin",
+				},
+			},
+			expected: []*computepb.Instance{dbAdmin, webAdmin},
+		},
+		{
+			name: "key only",
+			attributes: &SetAttributes{
+				MetadataKeys: []string{"enable-oslogin"},
+			},
+			expected: []*computepb.Instance{dbAdmin},
+		},
+		{
+			name: "value and key",
+			attributes: &SetAttributes{
+				Metadata: map[string]string{
+					"boundary-role": "web-*",
+				},
+				MetadataKeys: []string{"enable-oslogin"},
+			},
+			expected: []*computepb.Instance{},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, selectInstances(instances, tc.attributes))
+		})
+	}
+}
+
+func TestSelectInstancesByUptimeAndAge(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = time.Now })
+
+	warm := &computepb.Instance{
+		Name:               proto.String("warm"),
+		CreationTimestamp:  proto.String("2024-05-31T12:00:00.000-07:00"),
+		LastStartTimestamp: proto.String("2024-06-01T04:00:00.000-07:00"),
+	}
+	booting := &computepb.Instance{
+		Name:               proto.String("booting"),
+		CreationTimestamp:  proto.String("2024-06-01T04:55:00.000-07:00"),
+		LastStartTimestamp: proto.String("2024-06-01T04:55:00.000-07:00"),
+	}
+	legacy := &computepb.Instance{
+		Name:               proto.String("legacy"),
+		CreationTimestamp:  proto.String("2023-01-01T00:00:00.000-07:00"),
+		LastStartTimestamp: proto.String("2024-05-01T00:00:00.000-07:00"),
+	}
+	neverStarted := &computepb.Instance{
+		Name:              proto.String("never-started"),
+		CreationTimestamp: proto.String("2024-06-01T04:00:00.000-07:00"),
+	}
+	instances := []*computepb.Instance{warm, booting, legacy, neverStarted}
+
+	cases := []struct {
+		name       string
+		attributes *SetAttributes
+		expected   []*computepb.Instance
+	}{
+		{
+			name: "min uptime",
+			attributes: &SetAttributes{
+				MinUptime: 10 * time.Minute,
+			},
+			expected: []*computepb.Instance{warm, legacy},
+		},
+		{
+			name: "min age",
+			attributes: &SetAttributes{
+				MinAge: 90 * 24 * time.Hour,
+			},
+			expected: []*computepb.Instance{legacy},
+		},
+		{
+			name: "max age",
+			attributes: &SetAttributes{
+				MaxAge: 7 * 24 * time.Hour,
+			},
+			expected: []*computepb.Instance{warm, booting, neverStarted},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, selectInstances(instances, tc.attributes))
+		})
+	}
+}
+
+func TestSelectByStatus(t *testing.T) {
+	running := &computepb.Instance{Name: proto.String("running"), Status: proto.String("RUNNING")}
+	stopping := &computepb.Instance{Name: proto.String("stopping"), Status: proto.String("STOPPING")}
+	terminated := &computepb.Instance{Name: proto.String("terminated"), Status: proto.String("TERMINATED")}
+	instances := []*computepb.Instance{running, stopping, terminated}
+
+	require.Equal(t, instances, selectByStatus(instances, nil))
+	require.Equal(t, []*computepb.Instance{running}, selectByStatus(instances, []string{"RUNNING"}))
+	require.Equal(t, []*computepb.Instance{stopping, terminated}, selectByStatus(instances, []string{"STOPPING", "TERMINATED"}))
+}
+
+func TestSelectInstancesBySchedulingAndMaintenance(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = time.Now })
+
+	standard := &computepb.Instance{
+		Name: proto.String("standard"),
+		Scheduling: &computepb.Scheduling{
+			ProvisioningModel: proto.String("STANDARD"),
+		},
+	}
+	unset := &computepb.Instance{
+		Name: proto.String("unset"),
+	}
+	spot := &computepb.Instance{
+		Name: proto.String("spot"),
+		Scheduling: &computepb.Scheduling{
+			ProvisioningModel: proto.String("SPOT"),
+		},
+	}
+	preemptible := &computepb.Instance{
+		Name: proto.String("preemptible"),
+		Scheduling: &computepb.Scheduling{
+			ProvisioningModel: proto.String("STANDARD"),
+			Preemptible:       proto.Bool(true),
+		},
+	}
+	maintenanceSoon := &computepb.Instance{
+		Name: proto.String("maintenance-soon"),
+		ResourceStatus: &computepb.ResourceStatus{
+			UpcomingMaintenance: &computepb.UpcomingMaintenance{
+				WindowStartTime: proto.String("2024-06-01T13:00:00Z"),
+			},
+		},
+	}
+	maintenanceLater := &computepb.Instance{
+		Name: proto.String("maintenance-later"),
+		ResourceStatus: &computepb.ResourceStatus{
+			UpcomingMaintenance: &computepb.UpcomingMaintenance{
+				WindowStartTime: proto.String("2024-06-03T12:00:00Z"),
+			},
+		},
+	}
+	instances := []*computepb.Instance{standard, unset, spot, preemptible, maintenanceSoon, maintenanceLater}
+
+	cases := []struct {
+		name       string
+		attributes *SetAttributes
+		expected   []*computepb.Instance
+	}{
+		{
+			name: "standard only",
+			attributes: &SetAttributes{
+				ProvisioningModels: []string{"STANDARD"},
+			},
+			expected: []*computepb.Instance{standard, unset, preemptible, maintenanceSoon, maintenanceLater},
+		},
+		{
+			name: "spot only",
+			attributes: &SetAttributes{
+				ProvisioningModels: []string{"SPOT"},
+			},
+			expected: []*computepb.Instance{spot},
+		},
+		{
+			name: "exclude preemptible",
+			attributes: &SetAttributes{
+				ProvisioningModels: []string{"STANDARD"},
+				ExcludePreemptible: true,
+			},
+			expected: []*computepb.Instance{standard, unset, maintenanceSoon, maintenanceLater},
+		},
+		{
+			name: "maintenance horizon",
+			attributes: &SetAttributes{
+				MaintenanceHorizon: 24 * time.Hour,
+			},
+			expected: []*computepb.Instance{standard, unset, spot, preemptible, maintenanceLater},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, selectInstances(instances, tc.attributes))
+		})
+	}
+}
+
+func TestSelectInstancesByInstanceTemplate(t *testing.T) {
+	fromTemplate := func(name, template string) *computepb.Instance {
+		return &computepb.Instance{
+			Name: proto.String(name),
+			Metadata: &computepb.Metadata{
+				Items: []*computepb.Items{
+					{
+						Key:   proto.String("instance-template"),
+						Value: proto.String(template),
+					},
+				},
+			},
+		}
+	}
+	webV1 := fromTemplate("web-v1", "projects/123456789/global/instanceTemplates/web-20240101")
+	webV2 := fromTemplate("web-v2", "projects/123456789/global/instanceTemplates/web-20240201")
+	regionalWeb := fromTemplate("regional-web", "projects/123456789/regions/us-central1/instanceTemplates/web-20240201")
+	standalone := &computepb.Instance{
+		Name: proto.String("standalone"),
+	}
+	instances := []*computepb.Instance{webV1, webV2, regionalWeb, standalone}
+
+	cases := []struct {
+		name     string
+		template string
+		expected []*computepb.Instance
+	}{
+		{
+			name:     "bare name",
+			template: "web-20240201",
+			expected: []*computepb.Instance{webV2, regionalWeb},
+		},
+		{
+			name:     "prefix",
+			template: "web-*",
+			expected: []*computepb.Instance{webV1, webV2, regionalWeb},
+		},
+		{
+			name:     "full url with project id",
+			template: "https://www.googleapis.com/compute/v1/projects/test-project/global/instanceTemplates/web-20240101",
+			expected: []*computepb.Instance{webV1},
+		},
+		{
+			name:     "regional prefix",
+			template: "regions/us-central1/instanceTemplates/web-*",
+			expected: []*computepb.Instance{regionalWeb},
+		},
+		{
+			name:     "no match",
+			template: "db-*",
+			expected: []*computepb.Instance{},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, selectInstances(instances, &SetAttributes{InstanceTemplate: tc.template}))
+		})
+	}
+}
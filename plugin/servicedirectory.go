@@ -0,0 +1,117 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	pb "github.com/hashicorp/boundary/sdk/pbs/plugin"
+	servicedirectory "google.golang.org/api/servicedirectory/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// serviceDirectoryServiceName returns the resource name of the Service
+// Directory service of the set, given as namespace/service or resource
+// name, e.g.
+// projects/my-project/locations/us-central1/namespaces/prod/services/api.
+// Short names refer to a namespace in the catalog project and the region
+// of the catalog zone.
+func serviceDirectoryServiceName(attributes *SetAttributes, catalog *CatalogAttributes) string {
+	project, location := catalog.Project, zoneRegion(catalog.Zone)
+	namespace, service, _ := strings.Cut(attributes.ServiceDirectory, "/")
+	if strings.Count(attributes.ServiceDirectory, "/") > 1 {
+		segments := resourceSegments(attributes.ServiceDirectory)
+		if segments["projects"] != "" {
+			project = segments["projects"]
+		}
+		if segments["locations"] != "" {
+			location = segments["locations"]
+		}
+		namespace, service = segments["namespaces"], segments["services"]
+	}
+	return fmt.Sprintf("projects/%s/locations/%s/namespaces/%s/services/%s", project, location, namespace, service)
+}
+
+// validateServiceDirectoryService checks that a Service Directory service
+// is given as namespace/service or as a service resource name.
+func validateServiceDirectoryService(ref string) error {
+	if strings.Count(ref, "/") > 1 {
+		segments := resourceSegments(ref)
+		if segments["namespaces"] == "" || segments["services"] == "" {
+			return fmt.Errorf("invalid service %q, must be a resource name of the form projects/PROJECT/locations/LOCATION/namespaces/NAMESPACE/services/SERVICE", ref)
+		}
+		return nil
+	}
+	namespace, service, ok := strings.Cut(ref, "/")
+	if !ok || namespace == "" || service == "" {
+		return fmt.Errorf("invalid service %q, must be of the form NAMESPACE/SERVICE", ref)
+	}
+	return nil
+}
+
+// getServiceDirectoryHosts returns the endpoints of the Service Directory
+// service of the set with all of the annotations of the set as hosts.
+func (c *GoogleClient) getServiceDirectoryHosts(attributes *SetAttributes, catalog *CatalogAttributes) ([]*pb.ListHostsResponseHost, error) {
+	name := serviceDirectoryServiceName(attributes, catalog)
+
+	var hosts []*pb.ListHostsResponseHost
+	err := c.ServiceDirectoryService.Projects.Locations.Namespaces.Services.Endpoints.List(name).Pages(c.Context, func(resp *servicedirectory.ListEndpointsResponse) error {
+		for _, endpoint := range resp.Endpoints {
+			if !labelsMatch(endpoint.Annotations, attributes.Annotations, nil) {
+				continue
+			}
+			host, err := serviceDirectoryEndpointToHost(endpoint, attributes)
+			if err != nil {
+				return err
+			}
+			hosts = append(hosts, host)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "error listing endpoints of Service Directory service %s: %s", name, err)
+	}
+	return hosts, nil
+}
+
+// serviceDirectoryEndpointToHost converts a Service Directory endpoint to
+// a host. The port and annotations of the endpoint are reported as host
+// attributes.
+func serviceDirectoryEndpointToHost(endpoint *servicedirectory.Endpoint, attributes *SetAttributes) (*pb.ListHostsResponseHost, error) {
+	if endpoint.Name == "" {
+		return nil, errors.New("response integrity error: missing Service Directory endpoint name")
+	}
+
+	result := &pb.ListHostsResponseHost{
+		ExternalId:   endpoint.Name,
+		ExternalName: path.Base(endpoint.Name),
+	}
+	if endpoint.Address != "" {
+		family := addressFamily(endpoint.Address)
+		if len(attributes.AddressFamilies) == 0 || stringInSlice(attributes.AddressFamilies, family) {
+			result.IpAddresses = []string{endpoint.Address}
+		}
+	}
+
+	fields := make(map[string]*structpb.Value)
+	if endpoint.Port > 0 {
+		fields[ConstHostAttributePort] = structpb.NewNumberValue(float64(endpoint.Port))
+	}
+	if len(endpoint.Annotations) > 0 {
+		annotations := make(map[string]*structpb.Value, len(endpoint.Annotations))
+		for k, v := range endpoint.Annotations {
+			annotations[k] = structpb.NewStringValue(v)
+		}
+		fields[ConstHostAttributeAnnotations] = structpb.NewStructValue(&structpb.Struct{Fields: annotations})
+	}
+	if len(fields) > 0 {
+		result.Attributes = &structpb.Struct{Fields: fields}
+	}
+	return result, nil
+}
@@ -0,0 +1,99 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package plugin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	servicedirectory "google.golang.org/api/servicedirectory/v1"
+)
+
+const testServiceName = "projects/test-project/locations/us-central1/namespaces/prod/services/api"
+
+func TestGetServiceDirectoryHosts(t *testing.T) {
+	gclient := newTestClient(t, testRoutes{
+		"/v1/" + testServiceName + "/endpoints": serveJSON(`{"endpoints": [
+			{"name": "` + testServiceName + `/endpoints/api-1", "address": "10.0.0.2", "port": 8443, "annotations": {"tier": "web"}},
+			{"name": "` + testServiceName + `/endpoints/api-2", "address": "fd20::2", "port": 8443, "annotations": {"tier": "batch"}},
+			{"name": "` + testServiceName + `/endpoints/api-3"}
+		]}`),
+	})
+	catalog := testCatalog()
+
+	cases := []struct {
+		name         string
+		attributes   *SetAttributes
+		expected     []string
+		expectedAddr [][]string
+	}{
+		{
+			name:         "all",
+			attributes:   &SetAttributes{ServiceDirectory: "prod/api"},
+			expected:     []string{"api-1", "api-2", "api-3"},
+			expectedAddr: [][]string{{"10.0.0.2"}, {"fd20::2"}, nil},
+		},
+		{
+			name: "annotations",
+			attributes: &SetAttributes{
+				ServiceDirectory: testServiceName,
+				Annotations:      map[string]string{"tier": "web"},
+			},
+			expected:     []string{"api-1"},
+			expectedAddr: [][]string{{"10.0.0.2"}},
+		},
+		{
+			name: "address families",
+			attributes: &SetAttributes{
+				ServiceDirectory: "prod/api",
+				AddressFamilies:  []string{ConstAddressFamilyIPv4},
+			},
+			expected:     []string{"api-1", "api-2", "api-3"},
+			expectedAddr: [][]string{{"10.0.0.2"}, nil, nil},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			hosts, err := gclient.getServiceDirectoryHosts(tc.attributes, catalog)
+			require.NoError(err)
+
+			var ids []string
+			var addrs [][]string
+			for _, host := range hosts {
+				ids = append(ids, host.ExternalId)
+				addrs = append(addrs, host.IpAddresses)
+			}
+			var expectedIds []string
+			for _, name := range tc.expected {
+				expectedIds = append(expectedIds, testServiceName+"/endpoints/"+name)
+			}
+			require.Equal(expectedIds, ids)
+			require.Equal(tc.expectedAddr, addrs)
+		})
+	}
+}
+
+func TestServiceDirectoryEndpointToHost(t *testing.T) {
+	require := require.New(t)
+
+	host, err := serviceDirectoryEndpointToHost(&servicedirectory.Endpoint{
+		Name:        testServiceName + "/endpoints/api-1",
+		Address:     "10.0.0.2",
+		Port:        8443,
+		Annotations: map[string]string{"tier": "web"},
+	}, &SetAttributes{})
+	require.NoError(err)
+	require.Equal("api-1", host.ExternalName)
+	require.Equal(map[string]interface{}{
+		ConstHostAttributePort:        float64(8443),
+		ConstHostAttributeAnnotations: map[string]interface{}{"tier": "web"},
+	}, host.Attributes.AsMap())
+
+	_, err = serviceDirectoryEndpointToHost(&servicedirectory.Endpoint{}, &SetAttributes{})
+	require.ErrorContains(err, "missing Service Directory endpoint name")
+}
@@ -0,0 +1,163 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package plugin
+
+import (
+	"fmt"
+	"strings"
+
+	computepb "cloud.google.com/go/compute/apiv1/computepb"
+	cloudresourcemanager "google.golang.org/api/cloudresourcemanager/v3"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// defaultTagEndpoint is the Resource Manager endpoint serving the
+	// effective tags of zonal resources such as instances.
+	defaultTagEndpoint = "https://{zone}-cloudresourcemanager.googleapis.com/"
+
+	// effectiveTagsPageSize is the largest page size accepted by the
+	// effective tags listing.
+	effectiveTagsPageSize = 300
+)
+
+// validateTag checks a secure tag selector: tagKeys/ID, tagValues/ID, or
+// a short or namespaced tag key or value name such as env, env/prod,
+// 123456789/env or 123456789/env/prod.
+func validateTag(tag string) error {
+	segments := strings.Split(tag, "/")
+	for _, segment := range segments {
+		if segment == "" {
+			return fmt.Errorf("invalid tag %q, must not contain empty segments", tag)
+		}
+	}
+	switch {
+	case segments[0] == "tagKeys" || segments[0] == "tagValues":
+		if len(segments) != 2 {
+			return fmt.Errorf("invalid tag %q, must be of the form %s/ID", tag, segments[0])
+		}
+	case len(segments) > 3:
+		return fmt.Errorf("invalid tag %q, must be a short or namespaced tag key or value name", tag)
+	}
+	return nil
+}
+
+// tagMatches reports whether the effective tag matches a secure tag
+// selector. Names match the namespaced key or value of the tag on path
+// segment boundaries, so short names match the tag under any parent.
+func tagMatches(tag *cloudresourcemanager.EffectiveTag, want string) bool {
+	switch {
+	case strings.HasPrefix(want, "tagKeys/"):
+		return tag.TagKey == want
+	case strings.HasPrefix(want, "tagValues/"):
+		return tag.TagValue == want
+	}
+	if tag.NamespacedTagKey == want || strings.HasSuffix(tag.NamespacedTagKey, "/"+want) {
+		return true
+	}
+	// Value names include at least the key, a bare name is a key.
+	if !strings.Contains(want, "/") {
+		return false
+	}
+	return tag.NamespacedTagValue == want || strings.HasSuffix(tag.NamespacedTagValue, "/"+want)
+}
+
+// tagsMatch reports whether every secure tag selector matches one of the
+// effective tags.
+func tagsMatch(tags []*cloudresourcemanager.EffectiveTag, want []string) bool {
+	for _, w := range want {
+		found := false
+		for _, tag := range tags {
+			if tagMatches(tag, w) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// selectByTags returns the instances whose effective secure tags, which
+// include the tags inherited from their project, folders and
+// organization, match every wanted tag.
+func (c *GoogleClient) selectByTags(instances []*computepb.Instance, want []string) ([]*computepb.Instance, error) {
+	return selectConcurrently(instances, effectiveTagsConcurrency, func(instance *computepb.Instance) (bool, error) {
+		tags, err := c.getEffectiveTags(instance)
+		if err != nil {
+			return false, err
+		}
+		return tagsMatch(tags, want), nil
+	})
+}
+
+// getEffectiveTags returns the effective secure tags of the instance.
+// Effective tags of instances are only served by the zonal endpoint of
+// the Resource Manager API.
+func (c *GoogleClient) getEffectiveTags(instance *computepb.Instance) ([]*cloudresourcemanager.EffectiveTag, error) {
+	cacheKey := instance.GetSelfLink()
+
+	c.tagsMu.Lock()
+	tags, ok := c.effectiveTags[cacheKey]
+	c.tagsMu.Unlock()
+	if ok {
+		return tags, nil
+	}
+
+	project, zone, _ := instanceLocation(instance)
+	service, err := c.getTagService(zone)
+	if err != nil {
+		return nil, err
+	}
+
+	parent := fmt.Sprintf("//compute.googleapis.com/projects/%s/zones/%s/instances/%d", project, zone, instance.GetId())
+	tags = []*cloudresourcemanager.EffectiveTag{}
+	err = service.EffectiveTags.List().Parent(parent).PageSize(effectiveTagsPageSize).Pages(c.Context, func(resp *cloudresourcemanager.ListEffectiveTagsResponse) error {
+		tags = append(tags, resp.EffectiveTags...)
+		return nil
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "error listing effective tags for instance %s: %s", instance.GetSelfLink(), err)
+	}
+
+	c.tagsMu.Lock()
+	if c.effectiveTags == nil {
+		c.effectiveTags = make(map[string][]*cloudresourcemanager.EffectiveTag)
+	}
+	c.effectiveTags[cacheKey] = tags
+	c.tagsMu.Unlock()
+
+	return tags, nil
+}
+
+// getTagService returns the Resource Manager service for the zone,
+// creating it on first use.
+func (c *GoogleClient) getTagService(zone string) (*cloudresourcemanager.Service, error) {
+	c.tagsMu.Lock()
+	defer c.tagsMu.Unlock()
+
+	if service, ok := c.tagServices[zone]; ok {
+		return service, nil
+	}
+
+	endpoint := c.TagEndpoint
+	if endpoint == "" {
+		endpoint = defaultTagEndpoint
+	}
+	opts := append([]option.ClientOption{option.WithEndpoint(strings.ReplaceAll(endpoint, "{zone}", zone))}, c.TagServiceOptions...)
+	service, err := cloudresourcemanager.NewService(c.Context, opts...)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "error creating Resource Manager service for zone %s: %s", zone, err)
+	}
+
+	if c.tagServices == nil {
+		c.tagServices = make(map[string]*cloudresourcemanager.Service)
+	}
+	c.tagServices[zone] = service
+	return service, nil
+}
@@ -0,0 +1,124 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package plugin
+
+import (
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	computepb "cloud.google.com/go/compute/apiv1/computepb"
+	"github.com/stretchr/testify/require"
+	cloudresourcemanager "google.golang.org/api/cloudresourcemanager/v3"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestSelectByTags(t *testing.T) {
+	var requests atomic.Int32
+	gclient := newTestClient(t, testRoutes{
+		"/us-central1-a/v3/effectiveTags": func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			switch r.URL.Query().Get("parent") {
+			case "//compute.googleapis.com/projects/test-project/zones/us-central1-a/instances/1":
+				w.Write([]byte(`{"effectiveTags": [
+					{"tagKey": "tagKeys/10", "tagValue": "tagValues/100", "namespacedTagKey": "123456789/env", "namespacedTagValue": "123456789/env/prod", "inherited": true},
+					{"tagKey": "tagKeys/11", "tagValue": "tagValues/110", "namespacedTagKey": "test-project/team", "namespacedTagValue": "test-project/team/payments"}
+				]}`))
+			case "//compute.googleapis.com/projects/test-project/zones/us-central1-a/instances/2":
+				w.Write([]byte(`{"effectiveTags": [
+					{"tagKey": "tagKeys/10", "tagValue": "tagValues/101", "namespacedTagKey": "123456789/env", "namespacedTagValue": "123456789/env/dev", "inherited": true}
+				]}`))
+			default:
+				w.Write([]byte(`{}`))
+			}
+		},
+	})
+	newInstance := func(id uint64, name string) *computepb.Instance {
+		return &computepb.Instance{
+			Id:       proto.Uint64(id),
+			Name:     proto.String(name),
+			SelfLink: proto.String(testComputeURL + "/zones/us-central1-a/instances/" + name),
+		}
+	}
+	instances := []*computepb.Instance{
+		newInstance(1, "prod"),
+		newInstance(2, "dev"),
+		newInstance(3, "untagged"),
+	}
+
+	cases := []struct {
+		name     string
+		tags     []string
+		expected []string
+	}{
+		{
+			name:     "short value name",
+			tags:     []string{"env/prod"},
+			expected: []string{"prod"},
+		},
+		{
+			name:     "namespaced value name",
+			tags:     []string{"123456789/env/dev"},
+			expected: []string{"dev"},
+		},
+		{
+			name:     "key",
+			tags:     []string{"env"},
+			expected: []string{"prod", "dev"},
+		},
+		{
+			name:     "ids",
+			tags:     []string{"tagKeys/11", "tagValues/100"},
+			expected: []string{"prod"},
+		},
+		{
+			name: "no match",
+			tags: []string{"env/staging"},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			selected, err := gclient.selectByTags(instances, tc.tags)
+			require.NoError(err)
+
+			var names []string
+			for _, instance := range selected {
+				names = append(names, instance.GetName())
+			}
+			require.Equal(tc.expected, names)
+		})
+	}
+
+	// Effective tags are looked up once per instance for the lifetime
+	// of the client.
+	require.EqualValues(t, len(instances), requests.Load())
+}
+
+func TestTagMatches(t *testing.T) {
+	tag := &cloudresourcemanager.EffectiveTag{
+		TagKey:             "tagKeys/10",
+		TagValue:           "tagValues/100",
+		NamespacedTagKey:   "123456789/env",
+		NamespacedTagValue: "123456789/env/prod",
+	}
+	for _, want := range []string{"env", "env/prod", "123456789/env", "123456789/env/prod", "tagKeys/10", "tagValues/100"} {
+		require.True(t, tagMatches(tag, want), want)
+	}
+	for _, want := range []string{"prod", "vn/prod", "987654321/env", "tagKeys/11", "tagValues/101"} {
+		require.False(t, tagMatches(tag, want), want)
+	}
+}
+
+func TestValidateTag(t *testing.T) {
+	for _, tag := range []string{"env", "env/prod", "123456789/env/prod", "tagKeys/10", "tagValues/100"} {
+		require.NoError(t, validateTag(tag), tag)
+	}
+	require.ErrorContains(t, validateTag("env//prod"), "must not contain empty segments")
+	require.ErrorContains(t, validateTag("tagValues/100/extra"), "must be of the form tagValues/ID")
+	require.ErrorContains(t, validateTag("a/b/c/d"), "must be a short or namespaced tag key or value name")
+}
@@ -0,0 +1,126 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package plugin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"sort"
+	"testing"
+
+	compute "cloud.google.com/go/compute/apiv1"
+	cred "github.com/joatmon08/boundary-plugin-google/internal/credential"
+	"github.com/stretchr/testify/require"
+	container "google.golang.org/api/container/v1"
+	dataproc "google.golang.org/api/dataproc/v1"
+	"google.golang.org/api/option"
+	servicedirectory "google.golang.org/api/servicedirectory/v1"
+	sqladmin "google.golang.org/api/sqladmin/v1"
+	tpu "google.golang.org/api/tpu/v2"
+)
+
+const testComputeURL = "https://www.googleapis.com/compute/v1/projects/test-project"
+
+// testCatalog returns the catalog attributes used by tests, for the
+// test-project project in the us-central1-a zone.
+func testCatalog() *CatalogAttributes {
+	return &CatalogAttributes{
+		CredentialAttributes: &cred.CredentialAttributes{
+			Project: "test-project",
+			Zone:    "us-central1-a",
+		},
+	}
+}
+
+// testRoutes are the handlers of a fake Google API server by request
+// path. A path may be a path.Match pattern, exact paths are preferred
+// over patterns.
+type testRoutes map[string]http.HandlerFunc
+
+func (routes testRoutes) handler(requestPath string) http.HandlerFunc {
+	if handler, ok := routes[requestPath]; ok {
+		return handler
+	}
+	patterns := make([]string, 0, len(routes))
+	for pattern := range routes {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, requestPath); ok {
+			return routes[pattern]
+		}
+	}
+	return writeNotFound
+}
+
+// serveJSON returns a handler responding with the body.
+func serveJSON(body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}
+}
+
+// serveInstance responds with an instance named after the last element
+// of the request path.
+func serveInstance(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte(`{"name": "` + path.Base(r.URL.Path) + `", "selfLink": "https://www.googleapis.com` + r.URL.Path + `"}`))
+}
+
+// writeNotFound responds with the not found error of the Google APIs.
+func writeNotFound(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotFound)
+	w.Write([]byte(`{"error": {"code": 404, "message": "The resource was not found"}}`))
+}
+
+// newTestClient starts a fake Google API server serving the routes and
+// returns a client whose compute clients and API services all use it.
+// Effective tags are served under /{zone}/ on the same server.
+func newTestClient(t *testing.T, routes testRoutes) *GoogleClient {
+	t.Helper()
+	ctx := context.Background()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		routes.handler(r.URL.Path)(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	opts := []option.ClientOption{option.WithEndpoint(srv.URL), option.WithoutAuthentication()}
+	serviceOpts := []option.ClientOption{option.WithEndpoint(srv.URL + "/"), option.WithoutAuthentication()}
+	c := &GoogleClient{
+		Context:           ctx,
+		TagEndpoint:       srv.URL + "/{zone}/",
+		TagServiceOptions: []option.ClientOption{option.WithoutAuthentication()},
+	}
+	t.Cleanup(c.Close)
+
+	var err error
+	c.InstancesClient, err = compute.NewInstancesRESTClient(ctx, opts...)
+	require.NoError(t, err)
+	c.InstanceGroupClient, err = compute.NewInstanceGroupsRESTClient(ctx, opts...)
+	require.NoError(t, err)
+	c.RegionInstanceGroupClient, err = compute.NewRegionInstanceGroupsRESTClient(ctx, opts...)
+	require.NoError(t, err)
+	c.NetworkEndpointGroupClient, err = compute.NewNetworkEndpointGroupsRESTClient(ctx, opts...)
+	require.NoError(t, err)
+	c.BackendServiceClient, err = compute.NewBackendServicesRESTClient(ctx, opts...)
+	require.NoError(t, err)
+	c.RegionBackendServiceClient, err = compute.NewRegionBackendServicesRESTClient(ctx, opts...)
+	require.NoError(t, err)
+	c.FirewallClient, err = compute.NewFirewallsRESTClient(ctx, opts...)
+	require.NoError(t, err)
+	c.ContainerService, err = container.NewService(ctx, serviceOpts...)
+	require.NoError(t, err)
+	c.SQLAdminService, err = sqladmin.NewService(ctx, serviceOpts...)
+	require.NoError(t, err)
+	c.DataprocService, err = dataproc.NewService(ctx, serviceOpts...)
+	require.NoError(t, err)
+	c.TPUService, err = tpu.NewService(ctx, serviceOpts...)
+	require.NoError(t, err)
+	c.ServiceDirectoryService, err = servicedirectory.NewService(ctx, serviceOpts...)
+	require.NoError(t, err)
+	return c
+}
@@ -0,0 +1,71 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"path"
+
+	pb "github.com/hashicorp/boundary/sdk/pbs/plugin"
+	tpu "google.golang.org/api/tpu/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// getTPUHosts returns the TPU VM nodes of the catalog zone matching the
+// set as hosts. TPU VMs are not Compute Engine instances and are not
+// returned by the instance listing.
+func (c *GoogleClient) getTPUHosts(attributes *SetAttributes, catalog *CatalogAttributes) ([]*pb.ListHostsResponseHost, error) {
+	parent := fmt.Sprintf("projects/%s/locations/%s", catalog.Project, catalog.Zone)
+
+	var hosts []*pb.ListHostsResponseHost
+	err := c.TPUService.Projects.Locations.Nodes.List(parent).Pages(c.Context, func(resp *tpu.ListNodesResponse) error {
+		for _, node := range resp.Nodes {
+			if !labelsMatch(node.Labels, attributes.Labels, attributes.ExcludeLabels) {
+				continue
+			}
+			host, err := tpuNodeToHost(node, attributes)
+			if err != nil {
+				return err
+			}
+			hosts = append(hosts, host)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "error listing TPU nodes in %s: %s", parent, err)
+	}
+	return hosts, nil
+}
+
+// tpuNodeToHost converts a TPU node to a host with the addresses of every
+// worker of the node, so that all workers of a multi-host slice are
+// reachable. Internal addresses are reported before external ones.
+func tpuNodeToHost(node *tpu.Node, attributes *SetAttributes) (*pb.ListHostsResponseHost, error) {
+	if node.Name == "" {
+		return nil, errors.New("response integrity error: missing TPU node name")
+	}
+
+	result := &pb.ListHostsResponseHost{
+		ExternalId:   node.Name,
+		ExternalName: path.Base(node.Name),
+	}
+	for _, endpoint := range node.NetworkEndpoints {
+		addr := endpoint.IpAddress
+		if addr != "" && attributes.includesAddress(ConstAddressKindInternal, addressFamily(addr)) {
+			result.IpAddresses = appendDistinct(result.IpAddresses, &addr)
+		}
+	}
+	for _, endpoint := range node.NetworkEndpoints {
+		if endpoint.AccessConfig == nil {
+			continue
+		}
+		addr := endpoint.AccessConfig.ExternalIp
+		if addr != "" && attributes.includesAddress(ConstAddressKindExternal, addressFamily(addr)) {
+			result.IpAddresses = appendDistinct(result.IpAddresses, &addr)
+		}
+	}
+	return result, nil
+}
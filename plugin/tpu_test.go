@@ -0,0 +1,93 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package plugin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetTPUHosts(t *testing.T) {
+	gclient := newTestClient(t, testRoutes{
+		"/v2/projects/test-project/locations/us-central2-b/nodes": serveJSON(`{"nodes": [
+			{
+				"name": "projects/test-project/locations/us-central2-b/nodes/train-v4-32",
+				"labels": {"team": "ml"},
+				"networkEndpoints": [
+					{"ipAddress": "10.0.0.2", "port": 8470, "accessConfig": {"externalIp": "34.1.2.3"}},
+					{"ipAddress": "10.0.0.3", "port": 8470, "accessConfig": {"externalIp": "34.1.2.4"}},
+					{"ipAddress": "10.0.0.4", "port": 8470}
+				]
+			},
+			{
+				"name": "projects/test-project/locations/us-central2-b/nodes/serve-v5e",
+				"labels": {"team": "serving"},
+				"networkEndpoints": [
+					{"ipAddress": "10.0.1.2", "port": 8470}
+				]
+			}
+		]}`),
+	})
+	catalog := testCatalog()
+	catalog.Zone = "us-central2-b"
+
+	cases := []struct {
+		name         string
+		attributes   *SetAttributes
+		expected     []string
+		expectedAddr [][]string
+	}{
+		{
+			name:         "all",
+			attributes:   &SetAttributes{Type: ConstSetTypeTPU},
+			expected:     []string{"train-v4-32", "serve-v5e"},
+			expectedAddr: [][]string{{"10.0.0.2", "10.0.0.3", "10.0.0.4", "34.1.2.3", "34.1.2.4"}, {"10.0.1.2"}},
+		},
+		{
+			name: "labels and internal addresses",
+			attributes: &SetAttributes{
+				Type:         ConstSetTypeTPU,
+				Labels:       map[string]string{"team": "ml"},
+				AddressKinds: []string{ConstAddressKindInternal},
+			},
+			expected:     []string{"train-v4-32"},
+			expectedAddr: [][]string{{"10.0.0.2", "10.0.0.3", "10.0.0.4"}},
+		},
+		{
+			name: "exclude labels",
+			attributes: &SetAttributes{
+				Type:          ConstSetTypeTPU,
+				ExcludeLabels: map[string]string{"team": "ml"},
+			},
+			expected:     []string{"serve-v5e"},
+			expectedAddr: [][]string{{"10.0.1.2"}},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			hosts, err := gclient.getTPUHosts(tc.attributes, catalog)
+			require.NoError(err)
+
+			var ids, names []string
+			var addrs [][]string
+			for _, host := range hosts {
+				ids = append(ids, host.ExternalId)
+				names = append(names, host.ExternalName)
+				addrs = append(addrs, host.IpAddresses)
+			}
+			var expectedIds []string
+			for _, name := range tc.expected {
+				expectedIds = append(expectedIds, "projects/test-project/locations/us-central2-b/nodes/"+name)
+			}
+			require.Equal(expectedIds, ids)
+			require.Equal(tc.expected, names)
+			require.Equal(tc.expectedAddr, addrs)
+		})
+	}
+}